@@ -2,7 +2,7 @@ package adaptors
 
 import (
 	"context"
-	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -14,9 +14,13 @@ type Session struct {
 	UserID       string    `json:"-" xml:"-" yaml:"-"`
 	ExpiresAt    time.Time `json:"-" xml:"-" yaml:"-"`
 	RefreshUntil time.Time `json:"-" xml:"-" yaml:"-"`
+	// AuthenticationLevel and AuthenticationFactors record how strongly the
+	// session was authenticated.
+	AuthenticationLevel   auth.AuthenticationLevel `json:"-" xml:"-" yaml:"-"`
+	AuthenticationFactors []string                 `json:"-" xml:"-" yaml:"-"`
 }
 
-var _ auth.Session = &Session{}
+var _ auth.AuthenticatedSession = &Session{}
 
 func (s *Session) GetSessionID() string {
 	return s.SessionID
@@ -42,12 +46,30 @@ func (s *Session) SetExpiresAt(expiresAt time.Time) {
 	s.ExpiresAt = expiresAt
 }
 
+func (s *Session) GetAuthenticationLevel() auth.AuthenticationLevel {
+	return s.AuthenticationLevel
+}
+
+func (s *Session) SetAuthenticationLevel(level auth.AuthenticationLevel) {
+	s.AuthenticationLevel = level
+}
+
+func (s *Session) GetAuthenticationFactors() []string {
+	return s.AuthenticationFactors
+}
+
+func (s *Session) SetAuthenticationFactors(factors []string) {
+	s.AuthenticationFactors = factors
+}
+
 func (s *Session) Copy() auth.Session {
 	return &Session{
-		SessionID:    s.SessionID,
-		UserID:       s.UserID,
-		ExpiresAt:    s.ExpiresAt,
-		RefreshUntil: s.RefreshUntil,
+		SessionID:             s.SessionID,
+		UserID:                s.UserID,
+		ExpiresAt:             s.ExpiresAt,
+		RefreshUntil:          s.RefreshUntil,
+		AuthenticationLevel:   s.AuthenticationLevel,
+		AuthenticationFactors: slices.Clone(s.AuthenticationFactors),
 	}
 }
 
@@ -55,6 +77,12 @@ type InMemoryAdapter struct {
 	sessions sync.Map
 }
 
+var (
+	_ auth.SessionLister  = &InMemoryAdapter{}
+	_ auth.SessionRotator = &InMemoryAdapter{}
+	_ auth.Sweeper        = &InMemoryAdapter{}
+)
+
 func NewInMemoryAdapter() auth.SessionAdapter {
 	return &InMemoryAdapter{
 		sessions: sync.Map{},
@@ -64,7 +92,7 @@ func NewInMemoryAdapter() auth.SessionAdapter {
 func (a *InMemoryAdapter) GetSession(ctx context.Context, sessionID string) (auth.Session, error) {
 	value, found := a.sessions.Load(sessionID)
 	if !found {
-		return nil, fmt.Errorf("session not found")
+		return nil, auth.ErrSessionNotFound
 	}
 
 	return value.(*Session), nil
@@ -100,3 +128,45 @@ func (a *InMemoryAdapter) DeleteSession(ctx context.Context, sessionID string) e
 
 	return nil
 }
+
+// RotateSession moves the session with the old session ID to the new
+// session's ID.
+func (a *InMemoryAdapter) RotateSession(ctx context.Context, oldSessionID string, newSession auth.Session) error {
+	if _, found := a.sessions.LoadAndDelete(oldSessionID); !found {
+		return auth.ErrSessionNotFound
+	}
+
+	return a.InsertSession(ctx, newSession)
+}
+
+func (a *InMemoryAdapter) ListSessionsByUserID(ctx context.Context, userID string) ([]auth.Session, error) {
+	sessions := []auth.Session{}
+
+	a.sessions.Range(func(key any, value any) bool {
+		session := value.(*Session)
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+
+		return true
+	})
+
+	return sessions, nil
+}
+
+// DeleteExpiredSessions removes all expired sessions from memory. Use
+// auth.StartJanitor to call it periodically.
+func (a *InMemoryAdapter) DeleteExpiredSessions(ctx context.Context) error {
+	now := time.Now()
+
+	a.sessions.Range(func(key any, value any) bool {
+		session := value.(*Session)
+		if session.ExpiresAt.Before(now) {
+			a.sessions.Delete(key)
+		}
+
+		return true
+	})
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package adaptors
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+)
+
+// DefaultShards is the number of shards used by the ShardedInMemoryAdapter when
+// zero shards are requested.
+const DefaultShards = 32
+
+// ShardedInMemoryAdapter is an in-memory implementation of the
+// auth.SessionAdapter interface built for a large number of sessions. Sessions
+// are spread over lock-striped shards to reduce contention and a secondary
+// userID -> sessionIDs index makes DeleteSessionsByUserID and
+// ListSessionsByUserID proportional to the number of sessions the user has
+// instead of the number of sessions in the process.
+//
+// Writes lock the user shards before the session shard, in shard order, so the
+// index always matches the sessions that are stored.
+type ShardedInMemoryAdapter struct {
+	sessionShards []*sessionShard
+	userShards    []*userShard
+}
+
+type sessionShard struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+type userShard struct {
+	mu         sync.RWMutex
+	sessionIDs map[string]map[string]struct{}
+}
+
+var (
+	_ auth.SessionAdapter = &ShardedInMemoryAdapter{}
+	_ auth.SessionLister  = &ShardedInMemoryAdapter{}
+	_ auth.SessionRotator = &ShardedInMemoryAdapter{}
+	_ auth.Sweeper        = &ShardedInMemoryAdapter{}
+)
+
+// NewShardedInMemoryAdapter returns a new instance of ShardedInMemoryAdapter
+// with the given number of shards. DefaultShards is used when shards is zero.
+func NewShardedInMemoryAdapter(shards uint) auth.SessionAdapter {
+	if shards == 0 {
+		shards = DefaultShards
+	}
+
+	a := &ShardedInMemoryAdapter{
+		sessionShards: make([]*sessionShard, shards),
+		userShards:    make([]*userShard, shards),
+	}
+
+	for i := range a.sessionShards {
+		a.sessionShards[i] = &sessionShard{sessions: map[string]*Session{}}
+		a.userShards[i] = &userShard{sessionIDs: map[string]map[string]struct{}{}}
+	}
+
+	return a
+}
+
+func (a *ShardedInMemoryAdapter) GetSession(ctx context.Context, sessionID string) (auth.Session, error) {
+	shard := a.sessionShard(sessionID)
+
+	shard.mu.RLock()
+	session, found := shard.sessions[sessionID]
+	shard.mu.RUnlock()
+
+	if !found {
+		return nil, auth.ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+func (a *ShardedInMemoryAdapter) InsertSession(ctx context.Context, newSession auth.Session) error {
+	session := newSession.(*Session)
+
+	shard, previous, unlock := a.lockSession(session.SessionID, session.UserID)
+	defer unlock()
+
+	shard.sessions[session.SessionID] = session
+
+	if previous != nil && previous.UserID != session.UserID {
+		a.removeFromIndex(previous.UserID, previous.SessionID)
+	}
+
+	a.addToIndex(session.UserID, session.SessionID)
+
+	return nil
+}
+
+func (a *ShardedInMemoryAdapter) UpdateSession(ctx context.Context, newSession auth.Session) error {
+	return a.InsertSession(ctx, newSession)
+}
+
+func (a *ShardedInMemoryAdapter) DeleteSessionsByUserID(ctx context.Context, userID string) error {
+	shard := a.userShard(userID)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	for sessionID := range shard.sessionIDs[userID] {
+		sessionShard := a.sessionShard(sessionID)
+
+		sessionShard.mu.Lock()
+		delete(sessionShard.sessions, sessionID)
+		sessionShard.mu.Unlock()
+	}
+
+	delete(shard.sessionIDs, userID)
+
+	return nil
+}
+
+func (a *ShardedInMemoryAdapter) DeleteSession(ctx context.Context, sessionID string) error {
+	a.deleteSession(sessionID, func(*Session) bool { return true })
+
+	return nil
+}
+
+// RotateSession moves the session with the old session ID to the new
+// session's ID. The old session is removed first so that only one of two
+// concurrent rotations succeeds.
+func (a *ShardedInMemoryAdapter) RotateSession(ctx context.Context, oldSessionID string, newSession auth.Session) error {
+	if !a.deleteSession(oldSessionID, func(*Session) bool { return true }) {
+		return auth.ErrSessionNotFound
+	}
+
+	return a.InsertSession(ctx, newSession)
+}
+
+// ListSessionsByUserID returns all of the sessions for the given user.
+func (a *ShardedInMemoryAdapter) ListSessionsByUserID(ctx context.Context, userID string) ([]auth.Session, error) {
+	shard := a.userShard(userID)
+
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	sessions := make([]auth.Session, 0, len(shard.sessionIDs[userID]))
+	for sessionID := range shard.sessionIDs[userID] {
+		sessionShard := a.sessionShard(sessionID)
+
+		sessionShard.mu.RLock()
+		session, found := sessionShard.sessions[sessionID]
+		sessionShard.mu.RUnlock()
+
+		if found && session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
+
+// DeleteExpiredSessions removes all expired sessions from memory. Use
+// auth.StartJanitor to call it periodically.
+func (a *ShardedInMemoryAdapter) DeleteExpiredSessions(ctx context.Context) error {
+	now := time.Now()
+	expired := func(session *Session) bool { return session.ExpiresAt.Before(now) }
+
+	for _, shard := range a.sessionShards {
+		sessionIDs := []string{}
+
+		shard.mu.RLock()
+		for sessionID, session := range shard.sessions {
+			if expired(session) {
+				sessionIDs = append(sessionIDs, sessionID)
+			}
+		}
+		shard.mu.RUnlock()
+
+		for _, sessionID := range sessionIDs {
+			a.deleteSession(sessionID, expired)
+		}
+	}
+
+	return nil
+}
+
+// deleteSession deletes the session and removes it from the index when
+// shouldDelete returns true. It reports whether the session was deleted.
+func (a *ShardedInMemoryAdapter) deleteSession(sessionID string, shouldDelete func(*Session) bool) bool {
+	shard, session, unlock := a.lockSession(sessionID)
+	defer unlock()
+
+	if session == nil || !shouldDelete(session) {
+		return false
+	}
+
+	delete(shard.sessions, sessionID)
+	a.removeFromIndex(session.UserID, sessionID)
+
+	return true
+}
+
+// lockSession locks the user shards of the stored session and of the given
+// users, in order, followed by the session shard. It returns the stored session, which
+// is nil when there is none, and a function that releases the locks. The user
+// shards are always locked before the session shard so that the index always
+// matches the session shards.
+func (a *ShardedInMemoryAdapter) lockSession(sessionID string, userIDs ...string) (*sessionShard, *Session, func()) {
+	shard := a.sessionShard(sessionID)
+
+	for {
+		shard.mu.RLock()
+		session := shard.sessions[sessionID]
+		shard.mu.RUnlock()
+
+		lockedUserIDs := userIDs
+		if session != nil {
+			lockedUserIDs = append(slices.Clone(userIDs), session.UserID)
+		}
+
+		unlockUsers := a.lockUsers(lockedUserIDs)
+
+		shard.mu.Lock()
+		if shard.sessions[sessionID] == session {
+			return shard, session, func() {
+				shard.mu.Unlock()
+				unlockUsers()
+			}
+		}
+
+		// The session changed before the locks were held, try again with its
+		// new user.
+		shard.mu.Unlock()
+		unlockUsers()
+	}
+}
+
+// lockUsers locks the user shards of the given users in shard order and
+// returns a function that unlocks them.
+func (a *ShardedInMemoryAdapter) lockUsers(userIDs []string) func() {
+	shards := make([]int, 0, len(userIDs))
+	for _, userID := range userIDs {
+		index := a.userShardIndex(userID)
+		if !slices.Contains(shards, index) {
+			shards = append(shards, index)
+		}
+	}
+
+	slices.Sort(shards)
+
+	for _, index := range shards {
+		a.userShards[index].mu.Lock()
+	}
+
+	return func() {
+		for _, index := range shards {
+			a.userShards[index].mu.Unlock()
+		}
+	}
+}
+
+// addToIndex adds the session to the user's index. The user's shard must be
+// locked.
+func (a *ShardedInMemoryAdapter) addToIndex(userID string, sessionID string) {
+	shard := a.userShard(userID)
+
+	sessionIDs, found := shard.sessionIDs[userID]
+	if !found {
+		sessionIDs = map[string]struct{}{}
+		shard.sessionIDs[userID] = sessionIDs
+	}
+
+	sessionIDs[sessionID] = struct{}{}
+}
+
+// removeFromIndex removes the session from the user's index. The user's shard
+// must be locked.
+func (a *ShardedInMemoryAdapter) removeFromIndex(userID string, sessionID string) {
+	shard := a.userShard(userID)
+
+	sessionIDs, found := shard.sessionIDs[userID]
+	if !found {
+		return
+	}
+
+	delete(sessionIDs, sessionID)
+
+	if len(sessionIDs) == 0 {
+		delete(shard.sessionIDs, userID)
+	}
+}
+
+func (a *ShardedInMemoryAdapter) sessionShard(sessionID string) *sessionShard {
+	return a.sessionShards[fnv32(sessionID)%uint32(len(a.sessionShards))]
+}
+
+func (a *ShardedInMemoryAdapter) userShard(userID string) *userShard {
+	return a.userShards[a.userShardIndex(userID)]
+}
+
+func (a *ShardedInMemoryAdapter) userShardIndex(userID string) int {
+	return int(fnv32(userID) % uint32(len(a.userShards)))
+}
+
+// fnv32 is an allocation free FNV-1a hash used to pick shards.
+func fnv32(key string) uint32 {
+	hash := uint32(2166136261)
+
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+
+	return hash
+}
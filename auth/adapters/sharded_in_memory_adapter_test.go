@@ -0,0 +1,121 @@
+package adaptors_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	adaptors "github.com/lukeshay/g/auth/adapters"
+)
+
+const benchmarkSessions = 100_000
+
+var benchmarkAdapters = []struct {
+	name       string
+	newAdapter func() auth.SessionAdapter
+}{
+	{name: "SyncMap", newAdapter: adaptors.NewInMemoryAdapter},
+	{name: "Sharded", newAdapter: func() auth.SessionAdapter { return adaptors.NewShardedInMemoryAdapter(0) }},
+}
+
+func newBenchmarkAdapter(b *testing.B, newAdapter func() auth.SessionAdapter) auth.SessionAdapter {
+	b.Helper()
+
+	adapter := newAdapter()
+	expiresAt := time.Now().Add(time.Hour)
+
+	for i := 0; i < benchmarkSessions; i++ {
+		err := adapter.InsertSession(context.Background(), &adaptors.Session{
+			SessionID: fmt.Sprintf("session-%d", i),
+			UserID:    fmt.Sprintf("user-%d", i%1000),
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			b.Fatalf("error inserting session: %v", err)
+		}
+	}
+
+	return adapter
+}
+
+func BenchmarkGetSession(b *testing.B) {
+	for _, benchmark := range benchmarkAdapters {
+		b.Run(benchmark.name, func(b *testing.B) {
+			adapter := newBenchmarkAdapter(b, benchmark.newAdapter)
+			var counter atomic.Int64
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := counter.Add(1)
+
+					_, err := adapter.GetSession(context.Background(), fmt.Sprintf("session-%d", i%benchmarkSessions))
+					if err != nil {
+						b.Errorf("error getting session: %v", err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkInsertSession(b *testing.B) {
+	for _, benchmark := range benchmarkAdapters {
+		b.Run(benchmark.name, func(b *testing.B) {
+			adapter := benchmark.newAdapter()
+			expiresAt := time.Now().Add(time.Hour)
+			var counter atomic.Int64
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := counter.Add(1)
+
+					err := adapter.InsertSession(context.Background(), &adaptors.Session{
+						SessionID: fmt.Sprintf("session-%d", i),
+						UserID:    fmt.Sprintf("user-%d", i%1000),
+						ExpiresAt: expiresAt,
+					})
+					if err != nil {
+						b.Errorf("error inserting session: %v", err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkListSessionsByUserID(b *testing.B) {
+	for _, benchmark := range benchmarkAdapters {
+		b.Run(benchmark.name, func(b *testing.B) {
+			lister := newBenchmarkAdapter(b, benchmark.newAdapter).(auth.SessionLister)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := lister.ListSessionsByUserID(context.Background(), fmt.Sprintf("user-%d", i%1000))
+				if err != nil {
+					b.Fatalf("error listing sessions: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDeleteSessionsByUserID(b *testing.B) {
+	for _, benchmark := range benchmarkAdapters {
+		b.Run(benchmark.name, func(b *testing.B) {
+			adapter := newBenchmarkAdapter(b, benchmark.newAdapter)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := adapter.DeleteSessionsByUserID(context.Background(), fmt.Sprintf("user-%d", i%1000))
+				if err != nil {
+					b.Fatalf("error deleting sessions: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package sqladapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/lukeshay/g/auth/internal/sqlmigrate"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+const (
+	// MigrationsTableName is the table Migrate uses to track which of the
+	// session migrations have been applied.
+	MigrationsTableName = "auth_session_migrations"
+	// MigrationLocksTableName is the table Migrate uses to make sure only one
+	// process runs the session migrations at a time.
+	MigrationLocksTableName = "auth_session_migration_locks"
+)
+
+// Migrations contains the versioned schema migrations for the sessions table.
+// Migrations are never changed once released, new versions are appended
+// instead. You can run them with Migrate or add them to your own migrator.
+var Migrations = migrate.NewMigrations()
+
+// sessionV1 is a snapshot of the sessions table when it was first created. The
+// migrations must not depend on Session so that they keep producing the same
+// schema when the model changes.
+type sessionV1 struct {
+	bun.BaseModel `bun:"table:sessions"`
+
+	ID           string    `bun:",pk"`
+	UserID       string    `bun:",notnull"`
+	ExpiresAt    time.Time `bun:",notnull"`
+	RefreshUntil time.Time `bun:",notnull"`
+}
+
+func init() {
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000001",
+		Comment: "create_sessions_table",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateTable().Model((*sessionV1)(nil)).IfNotExists().Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropTable().Model((*sessionV1)(nil)).IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000002",
+		Comment: "create_sessions_user_id_index",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateIndex().
+				Model((*sessionV1)(nil)).
+				Index("sessions_user_id_idx").
+				Column("user_id").
+				IfNotExists().
+				Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropIndex().Index("sessions_user_id_idx").IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000003",
+		Comment: "create_sessions_expires_at_index",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateIndex().
+				Model((*sessionV1)(nil)).
+				Index("sessions_expires_at_idx").
+				Column("expires_at").
+				IfNotExists().
+				Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropIndex().Index("sessions_expires_at_idx").IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000004",
+		Comment: "add_sessions_authentication_columns",
+		// Sessions created before this migration were created after the
+		// password was verified, so they default to single factor, which is
+		// auth.DefaultAuthenticationLevel.
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewAddColumn().
+				Model((*sessionV1)(nil)).
+				ColumnExpr("authentication_level INTEGER NOT NULL DEFAULT 1").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.NewAddColumn().
+				Model((*sessionV1)(nil)).
+				ColumnExpr("authentication_factors VARCHAR").
+				Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropColumn().Model((*sessionV1)(nil)).Column("authentication_factors").Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.NewDropColumn().Model((*sessionV1)(nil)).Column("authentication_level").Exec(ctx)
+
+			return err
+		},
+	})
+}
+
+// Migrate applies all of the session migrations that have not been applied to
+// the database yet.
+func Migrate(ctx context.Context, db *bun.DB) error {
+	return sqlmigrate.Run(ctx, db, Migrations, MigrationsTableName, MigrationLocksTableName)
+}
@@ -0,0 +1,78 @@
+package sqladapter
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/uptrace/bun"
+)
+
+// Session is the default model for the sessions table created by
+// [Migrations]. You can use your own model instead as long as it maps to the
+// same table and columns.
+type Session struct {
+	bun.BaseModel `bun:"table:sessions"`
+
+	ID           string    `bun:",pk"`
+	UserID       string    `bun:",notnull"`
+	ExpiresAt    time.Time `bun:",notnull"`
+	RefreshUntil time.Time `bun:",notnull"`
+	// AuthenticationLevel and AuthenticationFactors record how strongly the
+	// session was authenticated. The factors are stored as JSON.
+	AuthenticationLevel   auth.AuthenticationLevel `bun:",notnull"`
+	AuthenticationFactors []string
+}
+
+var _ auth.AuthenticatedSession = (*Session)(nil)
+
+func (s *Session) GetSessionID() string {
+	return s.ID
+}
+
+func (s *Session) GetUserID() string {
+	return s.UserID
+}
+
+func (s *Session) GetExpiresAt() time.Time {
+	return s.ExpiresAt
+}
+
+func (s *Session) GetRefreshUntil() time.Time {
+	return s.RefreshUntil
+}
+
+func (s *Session) SetSessionID(id string) {
+	s.ID = id
+}
+
+func (s *Session) SetExpiresAt(expiresAt time.Time) {
+	s.ExpiresAt = expiresAt
+}
+
+func (s *Session) GetAuthenticationLevel() auth.AuthenticationLevel {
+	return s.AuthenticationLevel
+}
+
+func (s *Session) SetAuthenticationLevel(level auth.AuthenticationLevel) {
+	s.AuthenticationLevel = level
+}
+
+func (s *Session) GetAuthenticationFactors() []string {
+	return s.AuthenticationFactors
+}
+
+func (s *Session) SetAuthenticationFactors(factors []string) {
+	s.AuthenticationFactors = factors
+}
+
+func (s *Session) Copy() auth.Session {
+	return &Session{
+		ID:                    s.ID,
+		UserID:                s.UserID,
+		ExpiresAt:             s.ExpiresAt,
+		RefreshUntil:          s.RefreshUntil,
+		AuthenticationLevel:   s.AuthenticationLevel,
+		AuthenticationFactors: slices.Clone(s.AuthenticationFactors),
+	}
+}
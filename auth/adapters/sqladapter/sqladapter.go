@@ -0,0 +1,183 @@
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/uptrace/bun"
+)
+
+// SessionModel is the constraint for the session models the SQLAdapter can
+// store. T is the struct type and the pointer to it must implement
+// auth.Session. The model must map to a table with the id, user_id, and
+// expires_at columns. The tables created by [Migrations] satisfy this.
+type SessionModel[T any] interface {
+	*T
+	auth.Session
+}
+
+// SQLAdapter is an implementation of the auth.SessionAdapter interface that
+// stores sessions in any database supported by bun.
+type SQLAdapter[T any, PT SessionModel[T]] struct {
+	db bun.IDB
+}
+
+var (
+	_ auth.SessionLister  = (*SQLAdapter[Session, *Session])(nil)
+	_ auth.SessionRotator = (*SQLAdapter[Session, *Session])(nil)
+	_ auth.Sweeper        = (*SQLAdapter[Session, *Session])(nil)
+)
+
+type NewOptions struct {
+	// DB is the database or transaction the sessions are stored in.
+	DB bun.IDB
+}
+
+// New returns a new instance of SQLAdapter for the session model T.
+//
+//	adapter := sqladapter.New[sqladapter.Session](sqladapter.NewOptions{DB: db})
+func New[T any, PT SessionModel[T]](options NewOptions) auth.SessionAdapter {
+	return &SQLAdapter[T, PT]{
+		db: options.DB,
+	}
+}
+
+func (a *SQLAdapter[T, PT]) GetSession(ctx context.Context, sessionID string) (auth.Session, error) {
+	session := PT(new(T))
+
+	err := a.db.NewSelect().Model(session).Where("id = ?", sessionID).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (a *SQLAdapter[T, PT]) InsertSession(ctx context.Context, newSession auth.Session) error {
+	session, err := a.model(newSession)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.db.NewInsert().Model(session).Exec(ctx)
+
+	return err
+}
+
+func (a *SQLAdapter[T, PT]) UpdateSession(ctx context.Context, newSession auth.Session) error {
+	session, err := a.model(newSession)
+	if err != nil {
+		return err
+	}
+
+	result, err := a.db.NewUpdate().
+		Model(session).
+		ExcludeColumn("id").
+		Where("id = ?", session.GetSessionID()).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated > 0 {
+		return nil
+	}
+
+	// Some databases, such as MySQL, only count the rows that changed, so an
+	// update that writes the same values affects zero rows.
+	exists, err := a.db.NewSelect().Model(PT(nil)).Where("id = ?", session.GetSessionID()).Exists(ctx)
+	if err != nil {
+		return err
+	} else if !exists {
+		return auth.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (a *SQLAdapter[T, PT]) DeleteSessionsByUserID(ctx context.Context, userID string) error {
+	_, err := a.db.NewDelete().Model(PT(nil)).Where("user_id = ?", userID).Exec(ctx)
+
+	return err
+}
+
+func (a *SQLAdapter[T, PT]) DeleteSession(ctx context.Context, sessionID string) error {
+	_, err := a.db.NewDelete().Model(PT(nil)).Where("id = ?", sessionID).Exec(ctx)
+
+	return err
+}
+
+// RotateSession deletes the session with the old session ID and inserts the
+// new session in a single transaction.
+func (a *SQLAdapter[T, PT]) RotateSession(ctx context.Context, oldSessionID string, newSession auth.Session) error {
+	session, err := a.model(newSession)
+	if err != nil {
+		return err
+	}
+
+	return a.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewDelete().Model(PT(nil)).Where("id = ?", oldSessionID).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if deleted == 0 {
+			return auth.ErrSessionNotFound
+		}
+
+		_, err = tx.NewInsert().Model(session).Exec(ctx)
+
+		return err
+	})
+}
+
+// ListSessionsByUserID returns all of the sessions for the given user. The
+// user_id index created by Migrations keeps this cheap.
+func (a *SQLAdapter[T, PT]) ListSessionsByUserID(ctx context.Context, userID string) ([]auth.Session, error) {
+	models := []T{}
+
+	err := a.db.NewSelect().Model(&models).Where("user_id = ?", userID).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]auth.Session, len(models))
+	for i := range models {
+		sessions[i] = PT(&models[i])
+	}
+
+	return sessions, nil
+}
+
+// DeleteExpiredSessions deletes all expired sessions. The expires_at index
+// created by Migrations keeps this cheap.
+func (a *SQLAdapter[T, PT]) DeleteExpiredSessions(ctx context.Context) error {
+	_, err := a.db.NewDelete().Model(PT(nil)).Where("expires_at < ?", time.Now()).Exec(ctx)
+
+	return err
+}
+
+func (a *SQLAdapter[T, PT]) model(session auth.Session) (PT, error) {
+	model, ok := session.(PT)
+	if !ok {
+		return nil, fmt.Errorf("unexpected session type: %T", session)
+	}
+
+	return model, nil
+}
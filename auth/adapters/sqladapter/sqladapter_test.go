@@ -0,0 +1,311 @@
+package sqladapter_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/adapters/sqladapter"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+)
+
+func newTestAdapter(t *testing.T) (auth.SessionAdapter, *bun.DB) {
+	t.Helper()
+
+	sqldb, err := sql.Open(sqliteshim.ShimName, fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	err = sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("error migrating database: %v", err)
+	}
+
+	return sqladapter.New[sqladapter.Session](sqladapter.NewOptions{DB: db}), db
+}
+
+func newTestSession(id, userID string, expiresAt time.Time) *sqladapter.Session {
+	return &sqladapter.Session{
+		ID:           id,
+		UserID:       userID,
+		ExpiresAt:    expiresAt,
+		RefreshUntil: expiresAt.Add(time.Hour),
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	_, db := newTestAdapter(t)
+
+	err := sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("expected migrations to be idempotent, got %v", err)
+	}
+}
+
+func TestSQLAdapterCRUD(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	err := adapter.InsertSession(ctx, newTestSession("session", "user", expiresAt))
+	if err != nil {
+		t.Fatalf("error inserting session: %v", err)
+	}
+
+	session, err := adapter.GetSession(ctx, "session")
+	if err != nil {
+		t.Fatalf("error getting session: %v", err)
+	}
+
+	if session.GetUserID() != "user" || !session.GetExpiresAt().Equal(expiresAt) {
+		t.Fatalf("unexpected session: %+v", session)
+	}
+
+	updated := newTestSession("session", "user", expiresAt.Add(time.Hour))
+	updated.RefreshUntil = updated.RefreshUntil.Add(time.Hour)
+
+	err = adapter.UpdateSession(ctx, updated)
+	if err != nil {
+		t.Fatalf("error updating session: %v", err)
+	}
+
+	session, err = adapter.GetSession(ctx, "session")
+	if err != nil {
+		t.Fatalf("error getting session: %v", err)
+	}
+
+	model := session.(*sqladapter.Session)
+	if !model.ExpiresAt.Equal(updated.ExpiresAt) {
+		t.Errorf("expected expires at %v, got %v", updated.ExpiresAt, model.ExpiresAt)
+	}
+
+	if !model.RefreshUntil.Equal(updated.RefreshUntil) {
+		t.Errorf("expected refresh until %v, got %v", updated.RefreshUntil, model.RefreshUntil)
+	}
+
+	err = adapter.DeleteSession(ctx, "session")
+	if err != nil {
+		t.Fatalf("error deleting session: %v", err)
+	}
+
+	_, err = adapter.GetSession(ctx, "session")
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestSQLAdapterUpdateMissingSession(t *testing.T) {
+	adapter, _ := newTestAdapter(t)
+
+	err := adapter.UpdateSession(context.Background(), newTestSession("missing", "user", time.Now()))
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestSQLAdapterDeleteSessionsByUserID(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	expiresAt := time.Now().Add(time.Hour)
+
+	for _, session := range []*sqladapter.Session{
+		newTestSession("a", "user", expiresAt),
+		newTestSession("b", "user", expiresAt),
+		newTestSession("c", "other", expiresAt),
+	} {
+		err := adapter.InsertSession(ctx, session)
+		if err != nil {
+			t.Fatalf("error inserting session: %v", err)
+		}
+	}
+
+	err := adapter.DeleteSessionsByUserID(ctx, "user")
+	if err != nil {
+		t.Fatalf("error deleting sessions: %v", err)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		_, err = adapter.GetSession(ctx, id)
+		if !errors.Is(err, auth.ErrSessionNotFound) {
+			t.Fatalf("expected session %q to be deleted, got %v", id, err)
+		}
+	}
+
+	_, err = adapter.GetSession(ctx, "c")
+	if err != nil {
+		t.Fatalf("expected other user's session to remain, got %v", err)
+	}
+}
+
+func TestSQLAdapterDeleteExpiredSessions(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+
+	err := adapter.InsertSession(ctx, newTestSession("expired", "user", time.Now().Add(-time.Hour)))
+	if err != nil {
+		t.Fatalf("error inserting session: %v", err)
+	}
+
+	err = adapter.InsertSession(ctx, newTestSession("active", "user", time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatalf("error inserting session: %v", err)
+	}
+
+	err = adapter.(auth.Sweeper).DeleteExpiredSessions(ctx)
+	if err != nil {
+		t.Fatalf("error deleting expired sessions: %v", err)
+	}
+
+	_, err = adapter.GetSession(ctx, "expired")
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	_, err = adapter.GetSession(ctx, "active")
+	if err != nil {
+		t.Fatalf("expected active session to remain, got %v", err)
+	}
+}
+
+func TestSQLAdapterListSessionsByUserID(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	lister := adapter.(auth.SessionLister)
+	expiresAt := time.Now().Add(time.Hour)
+
+	for _, session := range []*sqladapter.Session{
+		newTestSession("a", "user", expiresAt),
+		newTestSession("b", "user", expiresAt),
+		newTestSession("c", "other", expiresAt),
+	} {
+		err := adapter.InsertSession(ctx, session)
+		if err != nil {
+			t.Fatalf("error inserting session: %v", err)
+		}
+	}
+
+	sessions, err := lister.ListSessionsByUserID(ctx, "user")
+	if err != nil {
+		t.Fatalf("error listing sessions: %v", err)
+	}
+
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	for _, session := range sessions {
+		if session.GetUserID() != "user" {
+			t.Errorf("expected only the user's sessions, got %+v", session)
+		}
+	}
+
+	sessions, err = lister.ListSessionsByUserID(ctx, "nobody")
+	if err != nil {
+		t.Fatalf("error listing sessions: %v", err)
+	}
+
+	if len(sessions) != 0 {
+		t.Fatalf("expected 0 sessions, got %d", len(sessions))
+	}
+}
+
+func TestSQLAdapterAuthenticationLevel(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+
+	session := newTestSession("session", "user", time.Now().Add(time.Hour))
+	session.AuthenticationLevel = auth.AuthenticationLevelSingleFactor
+	session.AuthenticationFactors = []string{"password"}
+
+	err := adapter.InsertSession(ctx, session)
+	if err != nil {
+		t.Fatalf("error inserting session: %v", err)
+	}
+
+	session.AuthenticationLevel = auth.AuthenticationLevelMultiFactor
+	session.AuthenticationFactors = []string{"password", "totp"}
+
+	err = adapter.UpdateSession(ctx, session)
+	if err != nil {
+		t.Fatalf("error updating session: %v", err)
+	}
+
+	stored, err := adapter.GetSession(ctx, "session")
+	if err != nil {
+		t.Fatalf("error getting session: %v", err)
+	}
+
+	model := stored.(*sqladapter.Session)
+	if model.AuthenticationLevel != auth.AuthenticationLevelMultiFactor {
+		t.Errorf("expected multi factor, got %v", model.AuthenticationLevel)
+	}
+
+	if len(model.AuthenticationFactors) != 2 {
+		t.Errorf("expected two factors, got %v", model.AuthenticationFactors)
+	}
+}
+
+func TestSQLAdapterRotateSession(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	rotator := adapter.(auth.SessionRotator)
+	expiresAt := time.Now().Add(time.Hour)
+
+	err := adapter.InsertSession(ctx, newTestSession("old", "user", expiresAt))
+	if err != nil {
+		t.Fatalf("error inserting session: %v", err)
+	}
+
+	err = rotator.RotateSession(ctx, "old", newTestSession("new", "user", expiresAt))
+	if err != nil {
+		t.Fatalf("error rotating session: %v", err)
+	}
+
+	_, err = adapter.GetSession(ctx, "old")
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected old session to be deleted, got %v", err)
+	}
+
+	_, err = adapter.GetSession(ctx, "new")
+	if err != nil {
+		t.Fatalf("expected new session, got %v", err)
+	}
+
+	err = rotator.RotateSession(ctx, "old", newTestSession("newer", "user", expiresAt))
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	_, err = adapter.GetSession(ctx, "newer")
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected failed rotation to insert nothing, got %v", err)
+	}
+}
+
+func TestSQLAdapterUpdateUnchangedSession(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	session := newTestSession("session", "user", time.Now().Add(time.Hour))
+
+	err := adapter.InsertSession(ctx, session)
+	if err != nil {
+		t.Fatalf("error inserting session: %v", err)
+	}
+
+	err = adapter.UpdateSession(ctx, session)
+	if err != nil {
+		t.Fatalf("expected updating with the same values to succeed, got %v", err)
+	}
+}
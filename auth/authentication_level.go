@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"fmt"
+)
+
+// AuthenticationLevel describes how strongly a session was authenticated.
+// Higher levels are stronger.
+type AuthenticationLevel int
+
+const (
+	// AuthenticationLevelNone is a session that has not verified any factor.
+	AuthenticationLevelNone AuthenticationLevel = iota
+	// AuthenticationLevelSingleFactor is a session that has verified one
+	// factor, such as a password. When the user has a second factor enabled,
+	// the session should only be allowed to complete the second factor.
+	AuthenticationLevelSingleFactor
+	// AuthenticationLevelMultiFactor is a session that has verified two or
+	// more factors, such as a password and a TOTP.
+	AuthenticationLevelMultiFactor
+)
+
+// DefaultAuthenticationLevel is the level of sessions that were created
+// without one. Sessions are created after the user verified a factor, so they
+// are single factor unless a level is set. CreateSession sets it on
+// AuthenticatedSessions, sessions that do not implement AuthenticatedSession
+// are treated as it, and the sessions table created by the sqladapter
+// migrations uses it as the column default.
+const DefaultAuthenticationLevel = AuthenticationLevelSingleFactor
+
+func (l AuthenticationLevel) String() string {
+	switch l {
+	case AuthenticationLevelNone:
+		return "none"
+	case AuthenticationLevelSingleFactor:
+		return "single_factor"
+	case AuthenticationLevelMultiFactor:
+		return "multi_factor"
+	default:
+		return fmt.Sprintf("level_%d", int(l))
+	}
+}
+
+// AuthenticatedSession is an optional interface a Session can implement to
+// record how strongly it was authenticated. Sessions that do not implement it
+// are treated as DefaultAuthenticationLevel.
+type AuthenticatedSession interface {
+	Session
+	GetAuthenticationLevel() AuthenticationLevel
+	SetAuthenticationLevel(AuthenticationLevel)
+	// GetAuthenticationFactors returns the factors the session has verified,
+	// such as "password" or "totp".
+	GetAuthenticationFactors() []string
+	SetAuthenticationFactors([]string)
+}
+
+// SessionAuthenticationLevel returns the authentication level of the session.
+func SessionAuthenticationLevel(session Session) AuthenticationLevel {
+	authenticatedSession, ok := session.(AuthenticatedSession)
+	if !ok {
+		return DefaultAuthenticationLevel
+	}
+
+	return authenticatedSession.GetAuthenticationLevel()
+}
+
+// RequireAuthenticationLevel returns ErrInsufficientAuthenticationLevel when
+// the session was authenticated with a lower level than the given level.
+func RequireAuthenticationLevel(session Session, level AuthenticationLevel) error {
+	if sessionLevel := SessionAuthenticationLevel(session); sessionLevel < level {
+		return fmt.Errorf("%w: session is %s, %s is required", ErrInsufficientAuthenticationLevel, sessionLevel, level)
+	}
+
+	return nil
+}
@@ -5,9 +5,34 @@ package auth
 // implement this interface for your specific use case.
 type Encrypter interface {
 	// Encrypt encrypts the given string and returns the encrypted string. The
-	// returned string should be base64 encoded.
+	// returned string should be base64 encoded, preferably with the URL-safe
+	// alphabet and without padding so it does not need to be quoted in cookies
+	// or escaped in URLs.
 	Encrypt(string) (string, error)
 	// Decrypt decrypts the given string and returns the decrypted string. The
-	// given string should be base64 encoded.
+	// given string should be base64 encoded. Decrypt should accept both the
+	// standard and URL-safe alphabets, with or without padding.
 	Decrypt(string) (string, error)
 }
+
+// KeyRotator is an optional interface an Encrypter can implement when it
+// supports decrypting values with more than one key. It is used to re-issue
+// values that were encrypted with an old key under the current key.
+type KeyRotator interface {
+	// NeedsRotation reports whether the given encrypted string was encrypted
+	// with a key other than the one currently used to encrypt.
+	NeedsRotation(string) bool
+}
+
+// AADEncrypter is an optional interface an Encrypter can implement to bind
+// encrypted values to additional data, such as the purpose of the value. A
+// value encrypted with one additional data cannot be decrypted with another,
+// so a value encrypted for one purpose cannot be used for another. NetAuth and
+// FastAuth bind session cookies to the cookie name.
+type AADEncrypter interface {
+	// EncryptWithAAD encrypts the given string bound to the additional data.
+	EncryptWithAAD(plaintext string, additionalData string) (string, error)
+	// DecryptWithAAD decrypts the given string. It fails if the string was not
+	// encrypted with EncryptWithAAD and the same additional data.
+	DecryptWithAAD(encrypted string, additionalData string) (string, error)
+}
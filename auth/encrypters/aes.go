@@ -4,10 +4,10 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/md5"
-	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
-	"io"
+	"fmt"
+	"strings"
 
 	"github.com/lukeshay/g/auth"
 )
@@ -15,12 +15,51 @@ import (
 // AesEncrypter is an implementation of the Encrypter interface that uses AES
 // encryption.
 type AesEncrypter struct {
-	gcmInstance cipher.AEAD
+	gcmInstance       cipher.AEAD
+	legacyGcmInstance cipher.AEAD
+	versioned         bool
 }
 
-// NewAesEncrypter returns a new instance of AesEncrypter.
+var _ auth.AADEncrypter = &AesEncrypter{}
+
+type NewAesGcmEncrypterOptions struct {
+	// Key is the 32 byte AES-256 key. Use KeyFromSecret or KeyFromPassphrase to
+	// derive it from a secret.
+	Key []byte
+	// LegacySecret is the secret that was passed to NewAesEncrypter. When set,
+	// values encrypted by NewAesEncrypter can still be decrypted so existing
+	// cookies keep working while you migrate.
+	LegacySecret string
+}
+
+// NewAesEncrypter returns a new instance of AesEncrypter. Values are encoded
+// with URL-safe base64 so they can be stored in cookies, values encoded with
+// standard base64 by earlier versions can still be decrypted.
+//
+// Deprecated: The key is derived from the secret with MD5, which only uses
+// half of the key space. Use NewAesGcmEncrypter instead and set LegacySecret to
+// keep decrypting existing values.
 func NewAesEncrypter(secret string) (auth.Encrypter, error) {
-	aesBlock, err := aes.NewCipher([]byte(md5Hash(secret)))
+	gcmInstance, err := newLegacyGcm(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AesEncrypter{
+		gcmInstance: gcmInstance,
+	}, nil
+}
+
+// NewAesGcmEncrypter returns a new instance of AesEncrypter that uses AES-256
+// in GCM mode with the given key. Encrypted values are prefixed with a format
+// version so that the format can change without breaking existing values.
+func NewAesGcmEncrypter(options NewAesGcmEncrypterOptions) (auth.Encrypter, error) {
+	err := validateKey(options.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	aesBlock, err := aes.NewCipher(options.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -30,39 +69,78 @@ func NewAesEncrypter(secret string) (auth.Encrypter, error) {
 		return nil, err
 	}
 
-	return &AesEncrypter{
+	encrypter := &AesEncrypter{
 		gcmInstance: gcmInstance,
-	}, nil
+		versioned:   true,
+	}
+
+	if options.LegacySecret != "" {
+		encrypter.legacyGcmInstance, err = newLegacyGcm(options.LegacySecret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return encrypter, nil
 }
 
 func (e *AesEncrypter) Encrypt(plaintext string) (string, error) {
-	nonce := make([]byte, e.gcmInstance.NonceSize())
-	_, err := io.ReadFull(rand.Reader, nonce)
+	if e.versioned {
+		return sealV1(e.gcmInstance, plaintext)
+	}
+
+	value, err := seal(e.gcmInstance, plaintext, nil)
 	if err != nil {
 		return "", err
 	}
 
-	value := e.gcmInstance.Seal(nonce, nonce, []byte(plaintext), nil)
-	result := base64.StdEncoding.EncodeToString(value)
-
-	return result, nil
+	return base64.RawURLEncoding.EncodeToString(value), nil
 }
 
 func (e *AesEncrypter) Decrypt(encrypted string) (string, error) {
-	ciphered, err := base64.StdEncoding.DecodeString(encrypted)
+	if strings.HasPrefix(encrypted, formatV1) {
+		if !e.versioned {
+			return "", fmt.Errorf("%w: unsupported format", auth.ErrDecrypt)
+		}
+
+		return openV1(e.gcmInstance, encrypted)
+	}
+
+	gcmInstance := e.gcmInstance
+	if e.versioned {
+		gcmInstance = e.legacyGcmInstance
+	}
+
+	if gcmInstance == nil {
+		return "", fmt.Errorf("%w: unsupported format", auth.ErrDecrypt)
+	}
+
+	ciphered, err := decodeBase64(encrypted)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: %w", auth.ErrDecrypt, err)
 	}
 
-	nonceSize := e.gcmInstance.NonceSize()
-	nonce, ciphertext := ciphered[:nonceSize], ciphered[nonceSize:]
+	return open(gcmInstance, ciphered, nil)
+}
+
+// EncryptWithAAD encrypts the plaintext bound to the additional data. The
+// result can only be decrypted by DecryptWithAAD with the same additional data.
+func (e *AesEncrypter) EncryptWithAAD(plaintext string, additionalData string) (string, error) {
+	return sealV2(e.gcmInstance, plaintext, additionalData)
+}
+
+func (e *AesEncrypter) DecryptWithAAD(encrypted string, additionalData string) (string, error) {
+	return openV2(e.gcmInstance, encrypted, additionalData)
+}
 
-	originalText, err := e.gcmInstance.Open(nil, nonce, ciphertext, nil)
+// newLegacyGcm returns the AES-GCM instance used by NewAesEncrypter.
+func newLegacyGcm(secret string) (cipher.AEAD, error) {
+	aesBlock, err := aes.NewCipher([]byte(md5Hash(secret)))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(originalText), nil
+	return cipher.NewGCM(aesBlock)
 }
 
 func md5Hash(input string) string {
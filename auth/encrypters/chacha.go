@@ -0,0 +1,81 @@
+package encrypters
+
+import (
+	"crypto/cipher"
+
+	"github.com/lukeshay/g/auth"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// ChaChaEncrypter is an implementation of the Encrypter interface that uses
+// ChaCha20-Poly1305 or XChaCha20-Poly1305 encryption. It uses the same key
+// derivation and versioned format as the AesEncrypter returned by
+// NewAesGcmEncrypter.
+type ChaChaEncrypter struct {
+	aead cipher.AEAD
+}
+
+var _ auth.AADEncrypter = &ChaChaEncrypter{}
+
+type NewChaChaEncrypterOptions struct {
+	// Key is the 32 byte key. Use KeyFromSecret or KeyFromPassphrase to derive
+	// it from a secret.
+	Key []byte
+}
+
+// NewChaCha20Poly1305Encrypter returns a new instance of ChaChaEncrypter that
+// uses ChaCha20-Poly1305. Nonces are random and 12 bytes, so a single key
+// should not encrypt more than 2^32 values. Use
+// NewXChaCha20Poly1305Encrypter if you need to encrypt more than that.
+func NewChaCha20Poly1305Encrypter(options NewChaChaEncrypterOptions) (auth.Encrypter, error) {
+	err := validateKey(options.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.New(options.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChaChaEncrypter{
+		aead: aead,
+	}, nil
+}
+
+// NewXChaCha20Poly1305Encrypter returns a new instance of ChaChaEncrypter that
+// uses XChaCha20-Poly1305. Nonces are random and 24 bytes, so nonce collisions
+// are not a concern no matter how many values are encrypted.
+func NewXChaCha20Poly1305Encrypter(options NewChaChaEncrypterOptions) (auth.Encrypter, error) {
+	err := validateKey(options.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(options.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChaChaEncrypter{
+		aead: aead,
+	}, nil
+}
+
+func (e *ChaChaEncrypter) Encrypt(plaintext string) (string, error) {
+	return sealV1(e.aead, plaintext)
+}
+
+func (e *ChaChaEncrypter) Decrypt(encrypted string) (string, error) {
+	return openV1(e.aead, encrypted)
+}
+
+// EncryptWithAAD encrypts the plaintext bound to the additional data. The
+// result can only be decrypted by DecryptWithAAD with the same additional data.
+func (e *ChaChaEncrypter) EncryptWithAAD(plaintext string, additionalData string) (string, error) {
+	return sealV2(e.aead, plaintext, additionalData)
+}
+
+func (e *ChaChaEncrypter) DecryptWithAAD(encrypted string, additionalData string) (string, error) {
+	return openV2(e.aead, encrypted, additionalData)
+}
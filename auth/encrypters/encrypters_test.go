@@ -0,0 +1,389 @@
+package encrypters_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/encrypters"
+)
+
+const plaintext = "7d1f0c2e9a4b4c8d9e0f1a2b3c4d5e6f"
+
+func key(b byte) []byte {
+	return bytes.Repeat([]byte{b}, encrypters.KeySize)
+}
+
+func newEncrypters(t *testing.T, b byte) map[string]auth.Encrypter {
+	t.Helper()
+
+	aesGcm, err := encrypters.NewAesGcmEncrypter(encrypters.NewAesGcmEncrypterOptions{Key: key(b)})
+	if err != nil {
+		t.Fatalf("error creating aes encrypter: %v", err)
+	}
+
+	aesLegacy, err := encrypters.NewAesEncrypter(string(key(b)))
+	if err != nil {
+		t.Fatalf("error creating legacy aes encrypter: %v", err)
+	}
+
+	chacha, err := encrypters.NewChaCha20Poly1305Encrypter(encrypters.NewChaChaEncrypterOptions{Key: key(b)})
+	if err != nil {
+		t.Fatalf("error creating chacha encrypter: %v", err)
+	}
+
+	xchacha, err := encrypters.NewXChaCha20Poly1305Encrypter(encrypters.NewChaChaEncrypterOptions{Key: key(b)})
+	if err != nil {
+		t.Fatalf("error creating xchacha encrypter: %v", err)
+	}
+
+	keyring, err := encrypters.NewKeyringEncrypter(encrypters.NewKeyringEncrypterOptions{
+		ActiveKeyID: "new",
+		Encrypters: map[string]auth.Encrypter{
+			"new": xchacha,
+			"old": aesGcm,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error creating keyring encrypter: %v", err)
+	}
+
+	hmacSigner, err := encrypters.NewHmacSigner(encrypters.NewHmacSignerOptions{Keys: [][]byte{key(b)}})
+	if err != nil {
+		t.Fatalf("error creating hmac signer: %v", err)
+	}
+
+	return map[string]auth.Encrypter{
+		"AesGcm":           aesGcm,
+		"AesLegacy":        aesLegacy,
+		"ChaCha20Poly1305": chacha,
+		"XChaCha20":        xchacha,
+		"Keyring":          keyring,
+		"HmacSigner":       hmacSigner,
+	}
+}
+
+// tamper returns every value that differs from the encrypted value by one
+// character. The trailing character and padding are skipped because unpadded
+// base64 ignores the unused bits of the last character.
+func tamper(encrypted string) []string {
+	trimmed := strings.TrimRight(encrypted, "=")
+	tampered := make([]string, 0, len(trimmed))
+
+	for i := 0; i < len(trimmed)-1; i++ {
+		replacement := byte('A')
+		if trimmed[i] == replacement {
+			replacement = 'B'
+		}
+
+		tampered = append(tampered, encrypted[:i]+string(replacement)+encrypted[i+1:])
+	}
+
+	return tampered
+}
+
+func TestRoundTrip(t *testing.T) {
+	for name, encrypter := range newEncrypters(t, 1) {
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := encrypter.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("error encrypting: %v", err)
+			}
+
+			decrypted, err := encrypter.Decrypt(encrypted)
+			if err != nil {
+				t.Fatalf("error decrypting: %v", err)
+			}
+
+			if decrypted != plaintext {
+				t.Fatalf("expected %q, got %q", plaintext, decrypted)
+			}
+		})
+	}
+}
+
+func TestRoundTripWithAAD(t *testing.T) {
+	for name, encrypter := range newEncrypters(t, 1) {
+		aadEncrypter, ok := encrypter.(auth.AADEncrypter)
+		if !ok {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := aadEncrypter.EncryptWithAAD(plaintext, "session")
+			if err != nil {
+				t.Fatalf("error encrypting: %v", err)
+			}
+
+			decrypted, err := aadEncrypter.DecryptWithAAD(encrypted, "session")
+			if err != nil {
+				t.Fatalf("error decrypting: %v", err)
+			}
+
+			if decrypted != plaintext {
+				t.Fatalf("expected %q, got %q", plaintext, decrypted)
+			}
+
+			for _, additionalData := range []string{"", "other"} {
+				_, err = aadEncrypter.DecryptWithAAD(encrypted, additionalData)
+				if !errors.Is(err, auth.ErrDecrypt) {
+					t.Errorf("expected ErrDecrypt with additional data %q, got %v", additionalData, err)
+				}
+			}
+
+			_, err = encrypter.Decrypt(encrypted)
+			if !errors.Is(err, auth.ErrDecrypt) {
+				t.Errorf("expected ErrDecrypt without additional data, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTamper(t *testing.T) {
+	for name, encrypter := range newEncrypters(t, 1) {
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := encrypter.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("error encrypting: %v", err)
+			}
+
+			for _, tampered := range tamper(encrypted) {
+				decrypted, err := encrypter.Decrypt(tampered)
+				if err == nil {
+					t.Fatalf("expected an error decrypting %q, got %q", tampered, decrypted)
+				}
+			}
+
+			for _, truncated := range []string{"", encrypted[:len(encrypted)/2]} {
+				_, err = encrypter.Decrypt(truncated)
+				if err == nil {
+					t.Errorf("expected an error decrypting %q", truncated)
+				}
+			}
+		})
+	}
+}
+
+func TestTamperWithAAD(t *testing.T) {
+	for name, encrypter := range newEncrypters(t, 1) {
+		aadEncrypter, ok := encrypter.(auth.AADEncrypter)
+		if !ok {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := aadEncrypter.EncryptWithAAD(plaintext, "session")
+			if err != nil {
+				t.Fatalf("error encrypting: %v", err)
+			}
+
+			for _, tampered := range tamper(encrypted) {
+				decrypted, err := aadEncrypter.DecryptWithAAD(tampered, "session")
+				if err == nil {
+					t.Fatalf("expected an error decrypting %q, got %q", tampered, decrypted)
+				}
+			}
+		})
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	others := newEncrypters(t, 2)
+
+	for name, encrypter := range newEncrypters(t, 1) {
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := encrypter.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("error encrypting: %v", err)
+			}
+
+			_, err = others[name].Decrypt(encrypted)
+			if !errors.Is(err, auth.ErrDecrypt) {
+				t.Fatalf("expected ErrDecrypt, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCrossEncrypter(t *testing.T) {
+	all := newEncrypters(t, 1)
+
+	for name, encrypter := range all {
+		encrypted, err := encrypter.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("error encrypting with %s: %v", name, err)
+		}
+
+		for otherName, other := range all {
+			if otherName == name {
+				continue
+			}
+
+			decrypted, err := other.Decrypt(encrypted)
+			if err == nil {
+				t.Errorf("%s decrypted a value encrypted by %s: %q", otherName, name, decrypted)
+			}
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := newEncrypters(t, 1)["XChaCha20"]
+	newKey := newEncrypters(t, 2)["XChaCha20"]
+
+	oldKeyring, err := encrypters.NewKeyringEncrypter(encrypters.NewKeyringEncrypterOptions{
+		ActiveKeyID: "1",
+		Encrypters:  map[string]auth.Encrypter{"1": oldKey},
+	})
+	if err != nil {
+		t.Fatalf("error creating keyring encrypter: %v", err)
+	}
+
+	newKeyring, err := encrypters.NewKeyringEncrypter(encrypters.NewKeyringEncrypterOptions{
+		ActiveKeyID: "2",
+		Encrypters:  map[string]auth.Encrypter{"1": oldKey, "2": newKey},
+	})
+	if err != nil {
+		t.Fatalf("error creating keyring encrypter: %v", err)
+	}
+
+	oldHmac, err := encrypters.NewHmacSigner(encrypters.NewHmacSignerOptions{Keys: [][]byte{key(1)}})
+	if err != nil {
+		t.Fatalf("error creating hmac signer: %v", err)
+	}
+
+	newHmac, err := encrypters.NewHmacSigner(encrypters.NewHmacSignerOptions{Keys: [][]byte{key(2), key(1)}})
+	if err != nil {
+		t.Fatalf("error creating hmac signer: %v", err)
+	}
+
+	tests := map[string]struct {
+		old auth.Encrypter
+		new auth.Encrypter
+	}{
+		"Keyring":    {old: oldKeyring, new: newKeyring},
+		"HmacSigner": {old: oldHmac, new: newHmac},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := test.old.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("error encrypting: %v", err)
+			}
+
+			decrypted, err := test.new.Decrypt(encrypted)
+			if err != nil || decrypted != plaintext {
+				t.Fatalf("expected %q, got %q: %v", plaintext, decrypted, err)
+			}
+
+			rotator := test.new.(auth.KeyRotator)
+			if !rotator.NeedsRotation(encrypted) {
+				t.Errorf("expected value encrypted with the old key to need rotation")
+			}
+
+			reencrypted, err := test.new.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("error encrypting: %v", err)
+			}
+
+			if rotator.NeedsRotation(reencrypted) {
+				t.Errorf("expected value encrypted with the active key to not need rotation")
+			}
+		})
+	}
+}
+
+func TestKeyringLegacyKeyIDWithHmacSigner(t *testing.T) {
+	signer, err := encrypters.NewHmacSigner(encrypters.NewHmacSignerOptions{Keys: [][]byte{key(1)}})
+	if err != nil {
+		t.Fatalf("error creating hmac signer: %v", err)
+	}
+
+	keyring, err := encrypters.NewKeyringEncrypter(encrypters.NewKeyringEncrypterOptions{
+		ActiveKeyID: "2",
+		Encrypters:  map[string]auth.Encrypter{"1": signer, "2": newEncrypters(t, 2)["XChaCha20"]},
+		LegacyKeyID: "1",
+	})
+	if err != nil {
+		t.Fatalf("error creating keyring encrypter: %v", err)
+	}
+
+	// Values signed before the keyring was used have the value.signature
+	// format, so the value must not be read as a key ID.
+	legacy, err := signer.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("error signing: %v", err)
+	}
+
+	decrypted, err := keyring.Decrypt(legacy)
+	if err != nil || decrypted != plaintext {
+		t.Fatalf("expected %q, got %q: %v", plaintext, decrypted, err)
+	}
+
+	if !keyring.(auth.KeyRotator).NeedsRotation(legacy) {
+		t.Errorf("expected legacy value to need rotation")
+	}
+
+	encrypted, err := keyring.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("error encrypting: %v", err)
+	}
+
+	decrypted, err = keyring.Decrypt(encrypted)
+	if err != nil || decrypted != plaintext {
+		t.Fatalf("expected %q, got %q: %v", plaintext, decrypted, err)
+	}
+}
+
+func TestHmacSignerRejectsUnversionedSignature(t *testing.T) {
+	signer, err := encrypters.NewHmacSigner(encrypters.NewHmacSignerOptions{Keys: [][]byte{key(1)}})
+	if err != nil {
+		t.Fatalf("error creating hmac signer: %v", err)
+	}
+
+	fingerprint := sha256.Sum256(key(1))
+	h := hmac.New(sha256.New, key(1))
+	h.Write([]byte(plaintext))
+
+	signature := append(fingerprint[:4], h.Sum(nil)...)
+	unversioned := plaintext + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	_, err = signer.Decrypt(unversioned)
+	if !errors.Is(err, auth.ErrDecrypt) {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+}
+
+func TestAesEncrypterURLSafe(t *testing.T) {
+	encrypter, err := encrypters.NewAesEncrypter(string(key(1)))
+	if err != nil {
+		t.Fatalf("error creating legacy aes encrypter: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		encrypted, err := encrypter.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("error encrypting: %v", err)
+		}
+
+		if strings.ContainsAny(encrypted, "+/=") {
+			t.Fatalf("expected URL-safe value, got %q", encrypted)
+		}
+
+		ciphered, err := base64.RawURLEncoding.DecodeString(encrypted)
+		if err != nil {
+			t.Fatalf("error decoding: %v", err)
+		}
+
+		decrypted, err := encrypter.Decrypt(base64.StdEncoding.EncodeToString(ciphered))
+		if err != nil || decrypted != plaintext {
+			t.Fatalf("expected standard base64 value to decrypt to %q, got %q: %v", plaintext, decrypted, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package encrypters
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+)
+
+const (
+	// formatV1 is the prefix of values encrypted with the first versioned
+	// format. The value after the prefix is the URL-safe, unpadded base64
+	// encoded nonce followed by the ciphertext. The ":" is not part of the
+	// base64 alphabet, so unversioned values can never be mistaken for
+	// versioned ones.
+	formatV1 = "v1:"
+	// formatV2 is the same as formatV1 except that the ciphertext is bound to
+	// additional data. Values in this format can only be decrypted with the
+	// same additional data.
+	formatV2 = "v2:"
+)
+
+// seal encrypts the plaintext with a random nonce and returns the nonce
+// followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext string, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", auth.ErrEncrypt, err)
+	}
+
+	return aead.Seal(nonce, nonce, []byte(plaintext), additionalData), nil
+}
+
+// open decrypts a value created by seal.
+func open(aead cipher.AEAD, ciphered []byte, additionalData []byte) (string, error) {
+	nonceSize := aead.NonceSize()
+	if len(ciphered) < nonceSize {
+		return "", fmt.Errorf("%w: ciphertext is too short", auth.ErrDecrypt)
+	}
+
+	nonce, ciphertext := ciphered[:nonceSize], ciphered[nonceSize:]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", auth.ErrDecrypt, err)
+	}
+
+	return string(plaintext), nil
+}
+
+// sealV1 encrypts the plaintext and encodes it in the v1 format.
+func sealV1(aead cipher.AEAD, plaintext string) (string, error) {
+	return sealVersioned(aead, formatV1, plaintext, nil)
+}
+
+// openV1 decrypts a value in the v1 format.
+func openV1(aead cipher.AEAD, encrypted string) (string, error) {
+	return openVersioned(aead, formatV1, encrypted, nil)
+}
+
+// sealV2 encrypts the plaintext bound to the additional data and encodes it in
+// the v2 format.
+func sealV2(aead cipher.AEAD, plaintext string, additionalData string) (string, error) {
+	return sealVersioned(aead, formatV2, plaintext, []byte(additionalData))
+}
+
+// openV2 decrypts a value in the v2 format.
+func openV2(aead cipher.AEAD, encrypted string, additionalData string) (string, error) {
+	return openVersioned(aead, formatV2, encrypted, []byte(additionalData))
+}
+
+func sealVersioned(aead cipher.AEAD, format string, plaintext string, additionalData []byte) (string, error) {
+	ciphered, err := seal(aead, plaintext, additionalData)
+	if err != nil {
+		return "", err
+	}
+
+	return format + base64.RawURLEncoding.EncodeToString(ciphered), nil
+}
+
+func openVersioned(aead cipher.AEAD, format string, encrypted string, additionalData []byte) (string, error) {
+	encoded, found := strings.CutPrefix(encrypted, format)
+	if !found {
+		return "", fmt.Errorf("%w: unsupported format", auth.ErrDecrypt)
+	}
+
+	ciphered, err := decodeBase64(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", auth.ErrDecrypt, err)
+	}
+
+	return open(aead, ciphered, additionalData)
+}
+
+// decodeBase64 decodes standard and URL-safe base64 with or without padding so
+// that values encoded before the encoding changed can still be decoded.
+func decodeBase64(encoded string) ([]byte, error) {
+	encoded = strings.TrimRight(encoded, "=")
+
+	if strings.ContainsAny(encoded, "-_") {
+		return base64.RawURLEncoding.DecodeString(encoded)
+	}
+
+	return base64.RawStdEncoding.DecodeString(encoded)
+}
@@ -0,0 +1,198 @@
+package encrypters
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+)
+
+// signatureSeparator separates the value from its signature. The value is
+// split at the last separator, so the value itself may contain it.
+const signatureSeparator = "."
+
+// fingerprintSize is the number of bytes of the key fingerprint in the
+// signature.
+const fingerprintSize = 4
+
+// signatureVersion is the first byte of signatures so that the MAC layout can
+// be changed without confusing old and new signatures.
+const signatureVersion byte = 1
+
+const (
+	// macModeValue is the mode byte of values signed without additional data.
+	macModeValue byte = iota
+	// macModeAAD is the mode byte of values signed with additional data, so an
+	// empty additional data is not the same as none.
+	macModeAAD
+)
+
+// HmacSigner is an implementation of the Encrypter interface that signs values
+// with HMAC-SHA256 instead of encrypting them. Use it when you only need to
+// know that a value has not been tampered with, such as a session ID in a
+// cookie, and do not need to hide it. Signed values are shorter than encrypted
+// ones.
+//
+// Signed values have the format value.signature, so the value must be safe to
+// store wherever the signed value is stored. The generators in this module
+// produce values that are safe to store in cookies.
+//
+// Values are signed with the first key and verified with any of the keys, so
+// keys can be rotated by adding a new key to the front of the list. The
+// signature starts with a short fingerprint of the key that created it so the
+// key can be found without trying every key.
+type HmacSigner struct {
+	keys []hmacKey
+}
+
+type hmacKey struct {
+	key         []byte
+	fingerprint []byte
+}
+
+var (
+	_ auth.AADEncrypter = &HmacSigner{}
+	_ auth.KeyRotator   = &HmacSigner{}
+)
+
+type NewHmacSignerOptions struct {
+	// Keys are the HMAC keys. Each key must be at least 32 bytes. The first key
+	// is used to sign values. Use KeyFromSecret or KeyFromPassphrase to derive
+	// them from secrets.
+	Keys [][]byte
+}
+
+// NewHmacSigner returns a new instance of HmacSigner.
+func NewHmacSigner(options NewHmacSignerOptions) (auth.Encrypter, error) {
+	if len(options.Keys) == 0 {
+		return nil, fmt.Errorf("at least one key is required")
+	}
+
+	keys := make([]hmacKey, len(options.Keys))
+	for i, key := range options.Keys {
+		if len(key) < KeySize {
+			return nil, fmt.Errorf("key must be at least %d bytes, got %d", KeySize, len(key))
+		}
+
+		fingerprint := sha256.Sum256(key)
+
+		keys[i] = hmacKey{
+			key:         append([]byte{}, key...),
+			fingerprint: fingerprint[:fingerprintSize],
+		}
+	}
+
+	return &HmacSigner{
+		keys: keys,
+	}, nil
+}
+
+// Encrypt signs the value. The value is not encrypted.
+func (s *HmacSigner) Encrypt(value string) (string, error) {
+	return s.sign(value, macModeValue, nil), nil
+}
+
+// Decrypt verifies the signature and returns the value.
+func (s *HmacSigner) Decrypt(signed string) (string, error) {
+	return s.verify(signed, macModeValue, nil)
+}
+
+// EncryptWithAAD signs the value and the additional data. The result can only
+// be verified by DecryptWithAAD with the same additional data.
+func (s *HmacSigner) EncryptWithAAD(value string, additionalData string) (string, error) {
+	return s.sign(value, macModeAAD, []byte(additionalData)), nil
+}
+
+func (s *HmacSigner) DecryptWithAAD(signed string, additionalData string) (string, error) {
+	return s.verify(signed, macModeAAD, []byte(additionalData))
+}
+
+// NeedsRotation reports whether the value was signed with a key other than the
+// first key.
+func (s *HmacSigner) NeedsRotation(signed string) bool {
+	_, signature, err := s.split(signed)
+	if err != nil {
+		return false
+	}
+
+	return !hmac.Equal(signature.fingerprint, s.keys[0].fingerprint)
+}
+
+// hmacSignature is a decoded signature.
+type hmacSignature struct {
+	fingerprint []byte
+	mac         []byte
+}
+
+func (s *HmacSigner) sign(value string, mode byte, additionalData []byte) string {
+	key := s.keys[0]
+
+	signature := []byte{signatureVersion}
+	signature = append(signature, key.fingerprint...)
+	signature = append(signature, mac(key.key, value, mode, additionalData)...)
+
+	return value + signatureSeparator + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// verify finds the key that signed the value and checks the signature in
+// constant time.
+func (s *HmacSigner) verify(signed string, mode byte, additionalData []byte) (string, error) {
+	value, signature, err := s.split(signed)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range s.keys {
+		if !hmac.Equal(signature.fingerprint, key.fingerprint) {
+			continue
+		}
+
+		if hmac.Equal(signature.mac, mac(key.key, value, mode, additionalData)) {
+			return value, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: invalid signature", auth.ErrDecrypt)
+}
+
+// split returns the value and the decoded signature of a signed value.
+func (s *HmacSigner) split(signed string) (string, hmacSignature, error) {
+	separatorIndex := strings.LastIndex(signed, signatureSeparator)
+	if separatorIndex == -1 {
+		return "", hmacSignature{}, fmt.Errorf("%w: signature not found", auth.ErrDecrypt)
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(signed[separatorIndex+len(signatureSeparator):])
+	if err != nil {
+		return "", hmacSignature{}, fmt.Errorf("%w: %w", auth.ErrDecrypt, err)
+	}
+
+	if len(decoded) != 1+fingerprintSize+sha256.Size || decoded[0] != signatureVersion {
+		return "", hmacSignature{}, fmt.Errorf("%w: invalid signature", auth.ErrDecrypt)
+	}
+
+	signature := hmacSignature{
+		fingerprint: decoded[1 : 1+fingerprintSize],
+		mac:         decoded[1+fingerprintSize:],
+	}
+
+	return signed[:separatorIndex], signature, nil
+}
+
+// mac returns the HMAC-SHA256 of the value. The mode byte and the length
+// prefixed additional data are always written so that values signed with and
+// without additional data, even when it is empty, can never be confused.
+func mac(key []byte, value string, mode byte, additionalData []byte) []byte {
+	h := hmac.New(sha256.New, key)
+
+	h.Write([]byte{mode})
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(additionalData))))
+	h.Write(additionalData)
+	h.Write([]byte(value))
+
+	return h.Sum(nil)
+}
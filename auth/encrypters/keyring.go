@@ -0,0 +1,143 @@
+package encrypters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+)
+
+// keyIDSeparator separates the key ID from the ciphertext. Key IDs cannot
+// contain it, but the values of some encrypters can, such as the
+// value.signature format of HmacSigner.
+const keyIDSeparator = "."
+
+// KeyringEncrypter is an implementation of the Encrypter interface that allows
+// the encryption key to be rotated without invalidating existing values. Values
+// are always encrypted with the active key and the key ID is prepended to the
+// ciphertext so that they can be decrypted with any of the keys in the keyring.
+//
+// To rotate a key, add a new key to the keyring and make it the active key.
+// Keep the old key in the keyring until all of the values encrypted with it
+// have expired or been re-encrypted.
+type KeyringEncrypter struct {
+	activeKeyID string
+	legacyKeyID string
+	encrypters  map[string]auth.Encrypter
+}
+
+var (
+	_ auth.AADEncrypter = &KeyringEncrypter{}
+	_ auth.KeyRotator   = &KeyringEncrypter{}
+)
+
+type NewKeyringEncrypterOptions struct {
+	// ActiveKeyID is the ID of the key used to encrypt new values.
+	ActiveKeyID string
+	// Encrypters maps key IDs to the Encrypter for that key. Key IDs cannot
+	// contain a ".".
+	Encrypters map[string]auth.Encrypter
+	// LegacyKeyID is the ID of the key used to decrypt values that do not
+	// contain a key ID, such as values encrypted before the keyring was used.
+	// Values without a key ID are rejected when it is empty.
+	LegacyKeyID string
+}
+
+// NewKeyringEncrypter returns a new instance of KeyringEncrypter.
+func NewKeyringEncrypter(options NewKeyringEncrypterOptions) (auth.Encrypter, error) {
+	for keyID := range options.Encrypters {
+		if keyID == "" || strings.Contains(keyID, keyIDSeparator) {
+			return nil, fmt.Errorf("invalid key id: %q", keyID)
+		}
+	}
+
+	if _, ok := options.Encrypters[options.ActiveKeyID]; !ok {
+		return nil, fmt.Errorf("active key id not found: %q", options.ActiveKeyID)
+	}
+
+	if _, ok := options.Encrypters[options.LegacyKeyID]; options.LegacyKeyID != "" && !ok {
+		return nil, fmt.Errorf("legacy key id not found: %q", options.LegacyKeyID)
+	}
+
+	encrypters := make(map[string]auth.Encrypter, len(options.Encrypters))
+	for keyID, encrypter := range options.Encrypters {
+		encrypters[keyID] = encrypter
+	}
+
+	return &KeyringEncrypter{
+		activeKeyID: options.ActiveKeyID,
+		legacyKeyID: options.LegacyKeyID,
+		encrypters:  encrypters,
+	}, nil
+}
+
+func (e *KeyringEncrypter) Encrypt(plaintext string) (string, error) {
+	encrypted, err := e.encrypters[e.activeKeyID].Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	return e.activeKeyID + keyIDSeparator + encrypted, nil
+}
+
+func (e *KeyringEncrypter) Decrypt(encrypted string) (string, error) {
+	keyID, ciphertext := e.splitKeyID(encrypted)
+
+	encrypter, ok := e.encrypters[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown key id %q", auth.ErrDecrypt, keyID)
+	}
+
+	return encrypter.Decrypt(ciphertext)
+}
+
+// EncryptWithAAD encrypts the plaintext bound to the additional data with the
+// active key. The Encrypter for the active key must implement AADEncrypter.
+func (e *KeyringEncrypter) EncryptWithAAD(plaintext string, additionalData string) (string, error) {
+	encrypter, ok := e.encrypters[e.activeKeyID].(auth.AADEncrypter)
+	if !ok {
+		return "", fmt.Errorf("%w: key %q does not support additional data", auth.ErrEncrypt, e.activeKeyID)
+	}
+
+	encrypted, err := encrypter.EncryptWithAAD(plaintext, additionalData)
+	if err != nil {
+		return "", err
+	}
+
+	return e.activeKeyID + keyIDSeparator + encrypted, nil
+}
+
+func (e *KeyringEncrypter) DecryptWithAAD(encrypted string, additionalData string) (string, error) {
+	keyID, ciphertext := e.splitKeyID(encrypted)
+
+	encrypter, ok := e.encrypters[keyID].(auth.AADEncrypter)
+	if !ok {
+		return "", fmt.Errorf("%w: key %q does not exist or support additional data", auth.ErrDecrypt, keyID)
+	}
+
+	return encrypter.DecryptWithAAD(ciphertext, additionalData)
+}
+
+// NeedsRotation reports whether the value was encrypted with a key other than
+// the active key.
+func (e *KeyringEncrypter) NeedsRotation(encrypted string) bool {
+	keyID, _ := e.splitKeyID(encrypted)
+
+	return keyID != e.activeKeyID
+}
+
+// splitKeyID returns the key ID and the ciphertext of the value. Values that do
+// not start with a known key ID are legacy values when LegacyKeyID is set,
+// because legacy values can contain the separator too.
+func (e *KeyringEncrypter) splitKeyID(encrypted string) (string, string) {
+	keyID, ciphertext, found := strings.Cut(encrypted, keyIDSeparator)
+	if !found {
+		return e.legacyKeyID, encrypted
+	}
+
+	if _, ok := e.encrypters[keyID]; !ok && e.legacyKeyID != "" {
+		return e.legacyKeyID, encrypted
+	}
+
+	return keyID, ciphertext
+}
@@ -0,0 +1,70 @@
+package encrypters
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+)
+
+// KeySize is the size in bytes of the keys used by the encrypters.
+const KeySize = 32
+
+// MinSaltSize is the minimum size in bytes of the salt given to
+// KeyFromPassphrase.
+const MinSaltSize = 16
+
+// The Argon2id parameters used by KeyFromPassphrase. Changing them changes the
+// derived keys.
+const (
+	passphraseTime    = 3
+	passphraseMemory  = 64 * 1024
+	passphraseThreads = 4
+)
+
+// KeyFromSecret derives a key from a high entropy secret, such as a random
+// value loaded from a secrets manager, using HKDF-SHA256. The info should
+// describe what the key is used for so that the same secret can be used to
+// derive independent keys.
+func KeyFromSecret(secret []byte, info string) ([]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("secret is empty")
+	}
+
+	key := make([]byte, KeySize)
+
+	_, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(info)), key)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving key: %w", err)
+	}
+
+	return key, nil
+}
+
+// KeyFromPassphrase derives a key from a low entropy passphrase using Argon2id
+// with the second profile recommended by RFC 9106, three passes over 64 MiB of
+// memory with four lanes. The salt must be random, at
+// least MinSaltSize bytes, and stored alongside your configuration because the
+// same salt is needed to derive the same key again. Derivation is deliberately
+// slow, so derive the key once at startup.
+func KeyFromPassphrase(passphrase string, salt []byte) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase is empty")
+	}
+
+	if len(salt) < MinSaltSize {
+		return nil, fmt.Errorf("salt must be at least %d bytes", MinSaltSize)
+	}
+
+	return argon2.IDKey([]byte(passphrase), salt, passphraseTime, passphraseMemory, passphraseThreads, KeySize), nil
+}
+
+func validateKey(key []byte) error {
+	if len(key) != KeySize {
+		return fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+
+	return nil
+}
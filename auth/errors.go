@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"errors"
+)
+
+// These errors are wrapped by the SessionService, adapters, encrypters,
+// NetAuth, and FastAuth so that callers can tell them apart with errors.Is. For
+// example, ErrSessionNotFound, ErrSessionExpired, and ErrInvalidCookie usually
+// mean the request is unauthorized while any other error is a server error.
+var (
+	// ErrSessionNotFound is returned when a session does not exist. Adapters
+	// should return this error when a session cannot be found.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionExpired is returned when a session exists but has expired.
+	ErrSessionExpired = errors.New("session is expired")
+	// ErrInvalidCookie is returned when the session cookie is missing or its
+	// value cannot be decrypted.
+	ErrInvalidCookie = errors.New("invalid session cookie")
+	// ErrEncrypt is returned when an Encrypter fails to encrypt a value.
+	ErrEncrypt = errors.New("error encrypting")
+	// ErrDecrypt is returned when an Encrypter fails to decrypt a value, such
+	// as when the value was tampered with or encrypted with a different key.
+	ErrDecrypt = errors.New("error decrypting")
+	// ErrInsufficientEntropy is returned when the Generator reports less
+	// entropy than MinEntropyBits.
+	ErrInsufficientEntropy = errors.New("generator has insufficient entropy")
+	// ErrNotSupported is returned when the SessionAdapter does not implement an
+	// optional interface required by the operation.
+	ErrNotSupported = errors.New("operation not supported by session adapter")
+	// ErrInsufficientAuthenticationLevel is returned when a session has not
+	// been authenticated strongly enough, such as when its second factor has
+	// not been verified yet.
+	ErrInsufficientAuthenticationLevel = errors.New("insufficient authentication level")
+)
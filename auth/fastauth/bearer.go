@@ -0,0 +1,89 @@
+package fastauth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/jwt"
+	"github.com/valyala/fasthttp"
+)
+
+// GetClaims verifies the bearer token in the request's Authorization header
+// with the Verifier and returns its claims. The claims are stored in the user
+// values under ClaimsContextKey.
+func (e *FastAuth) GetClaims(ctx *fasthttp.RequestCtx) (jwt.Claims, error) {
+	if claims, ok := ctx.UserValue(ClaimsContextKey).(jwt.Claims); ok {
+		return claims, nil
+	}
+
+	if e.verifier == nil {
+		return nil, fmt.Errorf("%w: bearer tokens are not accepted", jwt.ErrInvalidToken)
+	}
+
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing bearer token", jwt.ErrInvalidToken)
+	}
+
+	claims, err := e.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.SetUserValue(ClaimsContextKey, claims)
+
+	return claims, nil
+}
+
+// Authenticate returns middleware that only lets through requests with a
+// valid bearer token or session. Requests with a bearer token are verified
+// with the Verifier and their claims are stored under ClaimsContextKey. All
+// other requests need a session cookie and their session is stored under
+// SessionContextKey. It responds with 401 Unauthorized when the request is not
+// authenticated.
+func (e *FastAuth) Authenticate(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if _, ok := bearerToken(ctx); ok && e.verifier != nil {
+			_, err := e.GetClaims(ctx)
+			if err != nil {
+				// ctx.Error resets the response, so headers are set after it.
+				ctx.Error(fasthttp.StatusMessage(fasthttp.StatusUnauthorized), fasthttp.StatusUnauthorized)
+				ctx.Response.Header.Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+
+				return
+			}
+
+			next(ctx)
+
+			return
+		}
+
+		_, err := e.GetSession(ctx)
+		if errors.Is(err, auth.ErrSessionNotFound) || errors.Is(err, auth.ErrSessionExpired) || errors.Is(err, auth.ErrInvalidCookie) {
+			ctx.Error(fasthttp.StatusMessage(fasthttp.StatusUnauthorized), fasthttp.StatusUnauthorized)
+
+			if e.verifier != nil {
+				ctx.Response.Header.Set("WWW-Authenticate", "Bearer")
+			}
+
+			return
+		} else if err != nil {
+			ctx.Error(fasthttp.StatusMessage(fasthttp.StatusInternalServerError), fasthttp.StatusInternalServerError)
+
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+func bearerToken(ctx *fasthttp.RequestCtx) (string, bool) {
+	scheme, token, found := strings.Cut(string(ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
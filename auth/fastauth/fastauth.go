@@ -1,15 +1,22 @@
 package fastauth
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/jwt"
 	"github.com/valyala/fasthttp"
 )
 
 type contextKey string
 
-const SessionContextKey contextKey = "session"
+const (
+	SessionContextKey contextKey = "session"
+	// ClaimsContextKey is the user value key of the claims of a verified
+	// bearer token.
+	ClaimsContextKey contextKey = "claims"
+)
 
 type Validate func(*fasthttp.RequestCtx, auth.Session) error
 
@@ -17,31 +24,48 @@ type CookieOptions struct {
 	Name   string
 	Path   string
 	Secure bool
+	// AllowUnboundCookies allows session cookies that were not bound to the
+	// cookie name when they were encrypted, such as cookies created before the
+	// Encrypter supported additional data. Enable it while migrating and use
+	// ReissueCookie to bind existing cookies.
+	AllowUnboundCookies bool
 }
 
 type FastAuth struct {
 	service       *auth.SessionService
 	validate      Validate
 	cookieOptions CookieOptions
+	verifier      *jwt.Verifier
 }
 
 type NewOptions struct {
-	Adapter       auth.SessionAdapter
-	Encrypter     auth.Encrypter
-	Generator     auth.Generator
-	CookieOptions CookieOptions
-	Validate      Validate
+	Adapter   auth.SessionAdapter
+	Encrypter auth.Encrypter
+	Generator auth.Generator
+	// Hasher hashes session IDs before they are stored. Defaults to SHA-256.
+	Hasher auth.Hasher
+	// LegacySessionIDs enables the temporary migration of raw session IDs. See
+	// auth.NewSessionServiceOptions.
+	LegacySessionIDs bool
+	CookieOptions    CookieOptions
+	Validate         Validate
+	// Verifier verifies bearer tokens. Bearer tokens are not accepted when it
+	// is nil.
+	Verifier *jwt.Verifier
 }
 
 func New(options NewOptions) *FastAuth {
 	return &FastAuth{
 		service: auth.NewSessionService(auth.NewSessionServiceOptions{
-			Adapter:   options.Adapter,
-			Encrypter: options.Encrypter,
-			Generator: options.Generator,
+			Adapter:          options.Adapter,
+			Encrypter:        options.Encrypter,
+			Generator:        options.Generator,
+			Hasher:           options.Hasher,
+			LegacySessionIDs: options.LegacySessionIDs,
 		}),
 		cookieOptions: options.CookieOptions,
 		validate:      options.Validate,
+		verifier:      options.Verifier,
 	}
 }
 
@@ -72,10 +96,13 @@ func (a *FastAuth) GetSession(ctx *fasthttp.RequestCtx) (auth.Session, error) {
 	session, ok := ctx.UserValue(SessionContextKey).(auth.Session)
 	if !ok {
 		value := ctx.Request.Header.Cookie(a.cookieOptions.Name)
+		if len(value) == 0 {
+			return nil, fmt.Errorf("%w: cookie %s not found", auth.ErrInvalidCookie, a.cookieOptions.Name)
+		}
 
-		sessionID, err := a.service.DecryptSessionID(string(value))
+		sessionID, err := a.decryptSessionID(string(value))
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %w", auth.ErrInvalidCookie, err)
 		}
 
 		session, err = a.service.GetSession(ctx, sessionID)
@@ -123,6 +150,64 @@ func (e *FastAuth) GetSessionAndRefresh(ctx *fasthttp.RequestCtx, expiresAt time
 	return session, nil
 }
 
+// RotateSession moves the session to a new session ID and sets a cookie for
+// it. See auth.SessionService.RotateSession.
+func (e *FastAuth) RotateSession(ctx *fasthttp.RequestCtx, session auth.Session) (auth.Session, error) {
+	session, err := e.service.RotateSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie, err := e.CreateCookie(session)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Response.Header.SetCookie(cookie)
+
+	ctx.SetUserValue(SessionContextKey, session)
+
+	return session, nil
+}
+
+// RequireAuthenticationLevel gets the session and returns an error wrapping
+// auth.ErrInsufficientAuthenticationLevel when it was authenticated with a
+// lower level than the given level. Use it to keep sessions that have not
+// verified their second factor out of everything but the MFA endpoints.
+func (e *FastAuth) RequireAuthenticationLevel(ctx *fasthttp.RequestCtx, level auth.AuthenticationLevel) (auth.Session, error) {
+	session, err := e.GetSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = auth.RequireAuthenticationLevel(session, level)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// UpgradeSession raises the authentication level of the session and sets a
+// cookie for its new session ID. See auth.SessionService.UpgradeSession.
+func (e *FastAuth) UpgradeSession(ctx *fasthttp.RequestCtx, session auth.Session, level auth.AuthenticationLevel, factor string) (auth.Session, error) {
+	session, err := e.service.UpgradeSession(ctx, session, level, factor)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie, err := e.CreateCookie(session)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Response.Header.SetCookie(cookie)
+
+	ctx.SetUserValue(SessionContextKey, session)
+
+	return session, nil
+}
+
 func (e *FastAuth) InvalidateSession(ctx *fasthttp.RequestCtx) error {
 	session, err := e.GetSession(ctx)
 	if err != nil {
@@ -142,8 +227,33 @@ func (e *FastAuth) InvalidateSession(ctx *fasthttp.RequestCtx) error {
 	return nil
 }
 
+// ReissueCookie sets a new session cookie when the request's cookie was
+// encrypted with a key that is no longer the active key or was not bound to the
+// cookie name. This lets the keys of a keyring Encrypter be rotated without
+// signing anyone out.
+func (e *FastAuth) ReissueCookie(ctx *fasthttp.RequestCtx) error {
+	value := ctx.Request.Header.Cookie(e.cookieOptions.Name)
+	if len(value) == 0 || !e.cookieNeedsReissue(string(value)) {
+		return nil
+	}
+
+	session, err := e.GetSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	cookie, err := e.CreateCookie(session)
+	if err != nil {
+		return err
+	}
+
+	ctx.Response.Header.SetCookie(cookie)
+
+	return nil
+}
+
 func (e *FastAuth) CreateCookie(session auth.Session) (*fasthttp.Cookie, error) {
-	encryptedSessionID, err := e.service.EncryptSessionID(session.GetSessionID())
+	encryptedSessionID, err := e.service.EncryptSessionIDWithAAD(session.GetSessionID(), e.cookieOptions.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -159,3 +269,24 @@ func (e *FastAuth) CreateCookie(session auth.Session) (*fasthttp.Cookie, error)
 
 	return cookie, nil
 }
+
+// decryptSessionID decrypts the session ID bound to the cookie name. Unbound
+// session IDs are only decrypted when AllowUnboundCookies is set.
+func (e *FastAuth) decryptSessionID(value string) (string, error) {
+	sessionID, err := e.service.DecryptSessionIDWithAAD(value, e.cookieOptions.Name)
+	if err != nil && e.cookieOptions.AllowUnboundCookies {
+		return e.service.DecryptSessionID(value)
+	}
+
+	return sessionID, err
+}
+
+func (e *FastAuth) cookieNeedsReissue(value string) bool {
+	if e.service.SessionIDNeedsRotation(value) {
+		return true
+	}
+
+	_, err := e.service.DecryptSessionIDWithAAD(value, e.cookieOptions.Name)
+
+	return err != nil
+}
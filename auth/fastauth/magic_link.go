@@ -0,0 +1,176 @@
+package fastauth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/magiclink"
+	"github.com/valyala/fasthttp"
+)
+
+type MagicLinkHandlerOptions struct {
+	Service *magiclink.Service
+	// NewSession returns the session to create for the user with the email
+	// address. This is where users are looked up or signed up.
+	NewSession func(ctx context.Context, email string) (auth.Session, error)
+	// CookieName is the name of the cookie that binds the link to the browser.
+	// Defaults to magiclink.DefaultCookieName.
+	CookieName string
+	// RedirectURL is where the user is sent after they are signed in.
+	// Defaults to "/".
+	RedirectURL string
+	// OnError is called with the errors that are not returned to the client
+	// and when a link is reused, which can mean it was intercepted. Errors are
+	// ignored when it is nil.
+	OnError func(*fasthttp.RequestCtx, error)
+	// ConfirmPage renders the page the link opens. It must POST the "token"
+	// query parameter as the "token" form value to VerifyMagicLinkHandler.
+	// Defaults to magiclink.WriteConfirmPage.
+	ConfirmPage fasthttp.RequestHandler
+}
+
+// SendMagicLinkHandler returns a handler that emails a magic link to the
+// address in the "email" form value and sets a cookie that binds the link to
+// the browser. It responds with 202 Accepted, or 429 Too Many Requests when
+// the browser was sent a link to the address less than the Service's
+// ResendInterval ago.
+func (e *FastAuth) SendMagicLinkHandler(options MagicLinkHandlerOptions) fasthttp.RequestHandler {
+	options = withMagicLinkDefaults(options)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		if !ctx.IsPost() {
+			ctx.Error(fasthttp.StatusMessage(fasthttp.StatusMethodNotAllowed), fasthttp.StatusMethodNotAllowed)
+			ctx.Response.Header.Set("Allow", fasthttp.MethodPost)
+
+			return
+		}
+
+		email := string(ctx.FormValue("email"))
+		if email == "" {
+			ctx.Error("email is required", fasthttp.StatusBadRequest)
+
+			return
+		}
+
+		previousBrowserToken := string(ctx.Request.Header.Cookie(options.CookieName))
+
+		browserToken, err := options.Service.SendLink(ctx, email, previousBrowserToken)
+		if err != nil {
+			status, message, ok := magiclink.ClientError(err)
+			if !ok && options.OnError != nil {
+				options.OnError(ctx, err)
+			}
+
+			ctx.Error(message, status)
+
+			return
+		}
+
+		ctx.Response.Header.SetCookie(e.magicLinkCookie(options.CookieName, browserToken, time.Now().Add(options.Service.TTL())))
+
+		ctx.SetStatusCode(fasthttp.StatusAccepted)
+	}
+}
+
+// VerifyMagicLinkHandler returns the handler the link points to. A GET renders
+// ConfirmPage, which POSTs the link token back, so that link scanners and
+// prefetching do not use up the link. A POST verifies the link token in the
+// "token" form value against the browser's cookie, creates a session with
+// CreateNewSession, and redirects to RedirectURL. It responds with 400 Bad
+// Request when the link is invalid, expired, already used, or was requested
+// from a different browser.
+func (e *FastAuth) VerifyMagicLinkHandler(options MagicLinkHandlerOptions) fasthttp.RequestHandler {
+	options = withMagicLinkDefaults(options)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set("Referrer-Policy", "no-referrer")
+		ctx.Response.Header.Set("Cache-Control", "no-store")
+
+		if ctx.IsGet() || ctx.IsHead() {
+			options.ConfirmPage(ctx)
+
+			return
+		} else if !ctx.IsPost() {
+			ctx.Error(fasthttp.StatusMessage(fasthttp.StatusMethodNotAllowed), fasthttp.StatusMethodNotAllowed)
+			ctx.Response.Header.Set("Allow", "GET, HEAD, POST")
+
+			return
+		}
+
+		browserToken := string(ctx.Request.Header.Cookie(options.CookieName))
+
+		ctx.Response.Header.SetCookie(e.magicLinkCookie(options.CookieName, "", time.Unix(0, 0)))
+
+		linkToken, err := options.Service.VerifyLink(ctx, string(ctx.PostArgs().Peek("token")), browserToken)
+		if err != nil {
+			// A reused link can mean the link was intercepted, so it is
+			// reported along with the errors that are not the client's.
+			status, message, ok := magiclink.ClientError(err)
+			if (!ok || errors.Is(err, magiclink.ErrTokenReused)) && options.OnError != nil {
+				options.OnError(ctx, err)
+			}
+
+			ctx.Error(message, status)
+
+			return
+		}
+
+		newSession, err := options.NewSession(ctx, linkToken.Email)
+		if err == nil {
+			_, err = e.CreateNewSession(ctx, newSession)
+		}
+		if err != nil {
+			if options.OnError != nil {
+				options.OnError(ctx, err)
+			}
+
+			ctx.Error(fasthttp.StatusMessage(fasthttp.StatusInternalServerError), fasthttp.StatusInternalServerError)
+
+			return
+		}
+
+		ctx.Redirect(options.RedirectURL, fasthttp.StatusSeeOther)
+	}
+}
+
+func (e *FastAuth) magicLinkCookie(name string, value string, expiresAt time.Time) *fasthttp.Cookie {
+	cookie := &fasthttp.Cookie{}
+
+	cookie.SetValue(value)
+	cookie.SetHTTPOnly(true)
+	cookie.SetSecure(e.cookieOptions.Secure)
+	cookie.SetSameSite(fasthttp.CookieSameSiteLaxMode)
+	cookie.SetExpire(expiresAt)
+	cookie.SetPath(e.cookieOptions.Path)
+	cookie.SetKey(name)
+
+	return cookie
+}
+
+func withMagicLinkDefaults(options MagicLinkHandlerOptions) MagicLinkHandlerOptions {
+	if options.CookieName == "" {
+		options.CookieName = magiclink.DefaultCookieName
+	}
+
+	if options.RedirectURL == "" {
+		options.RedirectURL = "/"
+	}
+
+	if options.ConfirmPage == nil {
+		options.ConfirmPage = writeMagicLinkConfirmPage
+	}
+
+	return options
+}
+
+func writeMagicLinkConfirmPage(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("text/html; charset=utf-8")
+
+	if ctx.IsHead() {
+		return
+	}
+
+	_ = magiclink.WriteConfirmPage(ctx, string(ctx.QueryArgs().Peek("token")))
+}
@@ -12,3 +12,16 @@ type Generator interface {
 	// The Coopenhagen Book.
 	Generate() (string, error)
 }
+
+// MinEntropyBits is the minimum entropy of session IDs recommended by The
+// Copenhagen Book.
+const MinEntropyBits = 120
+
+// EntropyGenerator is an optional interface a Generator can implement to
+// report the entropy of the strings it generates. The SessionService refuses
+// to create sessions with a Generator that reports less than MinEntropyBits.
+type EntropyGenerator interface {
+	Generator
+	// EntropyBits returns the number of random bits in each generated string.
+	EntropyBits() uint
+}
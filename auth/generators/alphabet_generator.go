@@ -0,0 +1,110 @@
+package generators
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+)
+
+// alphabetGenerator generates random strings from an alphabet. Characters are
+// picked with rejection sampling so that every character is equally likely,
+// unlike taking a random byte modulo the size of the alphabet.
+type alphabetGenerator struct {
+	alphabet  string
+	length    uint
+	groupSize uint
+	separator string
+}
+
+func newAlphabetGenerator(alphabet string, length uint, groupSize uint, separator string) *alphabetGenerator {
+	return &alphabetGenerator{
+		alphabet:  alphabet,
+		length:    length,
+		groupSize: groupSize,
+		separator: separator,
+	}
+}
+
+func (g *alphabetGenerator) Generate() (string, error) {
+	size := len(g.alphabet)
+	// limit is the largest multiple of size that fits in a byte. Bytes greater
+	// than or equal to it are rejected so that the modulo is not biased.
+	limit := 256 - 256%size
+
+	result := strings.Builder{}
+	buffer := make([]byte, g.length*2)
+	count := uint(0)
+
+	for count < g.length {
+		_, err := rand.Read(buffer)
+		if err != nil {
+			return "", fmt.Errorf("error reading random bytes: %w", err)
+		}
+
+		for _, b := range buffer {
+			if int(b) >= limit {
+				continue
+			}
+
+			if count > 0 && g.groupSize > 0 && count%g.groupSize == 0 {
+				result.WriteString(g.separator)
+			}
+
+			result.WriteByte(g.alphabet[int(b)%size])
+			count++
+
+			if count == g.length {
+				break
+			}
+		}
+	}
+
+	return result.String(), nil
+}
+
+func (g *alphabetGenerator) EntropyBits() uint {
+	return uint(float64(g.length) * math.Log2(float64(len(g.alphabet))))
+}
+
+type AlphabetGenerator struct {
+	base *alphabetGenerator
+}
+
+var _ auth.EntropyGenerator = &AlphabetGenerator{}
+
+// NewAlphabetGenerator creates a new generator that generates strings of the
+// given length from the characters in the alphabet. The alphabet must contain
+// between 2 and 128 unique ASCII characters.
+func NewAlphabetGenerator(alphabet string, length uint) (auth.Generator, error) {
+	if len(alphabet) < 2 || len(alphabet) > 128 {
+		return nil, fmt.Errorf("alphabet must contain between 2 and 128 characters")
+	}
+
+	seen := map[rune]bool{}
+	for _, c := range alphabet {
+		if c > math.MaxInt8 {
+			return nil, fmt.Errorf("alphabet must only contain ASCII characters")
+		}
+
+		if seen[c] {
+			return nil, fmt.Errorf("alphabet contains %q more than once", c)
+		}
+
+		seen[c] = true
+	}
+
+	return &AlphabetGenerator{
+		base: newAlphabetGenerator(alphabet, length, 0, ""),
+	}, nil
+}
+
+func (g *AlphabetGenerator) Generate() (string, error) {
+	return g.base.Generate()
+}
+
+func (g *AlphabetGenerator) EntropyBits() uint {
+	return g.base.EntropyBits()
+}
@@ -0,0 +1,116 @@
+package generators_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/generators"
+)
+
+// printableASCII has 95 characters. 256 is not a multiple of 95, so taking a
+// random byte modulo the size would make the first 66 characters 50% more
+// likely than the others.
+func printableASCII() string {
+	alphabet := strings.Builder{}
+	for c := byte(' '); c <= '~'; c++ {
+		alphabet.WriteByte(c)
+	}
+
+	return alphabet.String()
+}
+
+func TestAlphabetGeneratorUniform(t *testing.T) {
+	alphabet := printableASCII()
+
+	generator, err := generators.NewAlphabetGenerator(alphabet, 1000)
+	if err != nil {
+		t.Fatalf("error creating generator: %v", err)
+	}
+
+	counts := map[rune]int{}
+	for i := 0; i < 200; i++ {
+		value, err := generator.Generate()
+		if err != nil {
+			t.Fatalf("error generating: %v", err)
+		}
+
+		if len(value) != 1000 {
+			t.Fatalf("expected 1000 characters, got %d", len(value))
+		}
+
+		for _, c := range value {
+			counts[c]++
+		}
+	}
+
+	expected := 200 * 1000 / len(alphabet)
+	for _, c := range alphabet {
+		if counts[c] < expected*85/100 || counts[c] > expected*115/100 {
+			t.Errorf("expected about %d of %q, got %d", expected, c, counts[c])
+		}
+	}
+}
+
+func TestNewAlphabetGeneratorErrors(t *testing.T) {
+	tests := map[string]string{
+		"too short":  "a",
+		"duplicates": "abca",
+		"non ascii":  "abcé",
+		"too long":   printableASCII() + strings.Repeat("\x00", 34),
+	}
+
+	for name, alphabet := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := generators.NewAlphabetGenerator(alphabet, 10)
+			if err == nil {
+				t.Fatalf("expected an error for %q", alphabet)
+			}
+		})
+	}
+
+	allASCII := strings.Builder{}
+	for c := 0; c < 128; c++ {
+		allASCII.WriteByte(byte(c))
+	}
+
+	_, err := generators.NewAlphabetGenerator(allASCII.String(), 10)
+	if err != nil {
+		t.Fatalf("expected all 128 ASCII characters to be accepted, got %v", err)
+	}
+}
+
+func TestCodeGenerators(t *testing.T) {
+	tests := map[string]struct {
+		generator auth.Generator
+		pattern   string
+		bits      uint
+	}{
+		"Numeric":            {generator: generators.NewNumericGenerator(6), pattern: `^[0-9]{6}$`, bits: 19},
+		"Crockford":          {generator: generators.NewCrockfordGenerator(16, 4), pattern: `^[0-9A-HJKMNP-TV-Z]{4}(-[0-9A-HJKMNP-TV-Z]{4}){3}$`, bits: 80},
+		"CrockfordUngrouped": {generator: generators.NewCrockfordGenerator(10, 0), pattern: `^[0-9A-HJKMNP-TV-Z]{10}$`, bits: 50},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pattern := regexp.MustCompile(test.pattern)
+
+			for i := 0; i < 100; i++ {
+				value, err := test.generator.Generate()
+				if err != nil {
+					t.Fatalf("error generating: %v", err)
+				}
+
+				if !pattern.MatchString(value) {
+					t.Fatalf("expected %q to match %s", value, test.pattern)
+				}
+			}
+
+			bits := test.generator.(auth.EntropyGenerator).EntropyBits()
+			if bits != test.bits {
+				t.Fatalf("expected %d bits, got %d", test.bits, bits)
+			}
+		})
+	}
+}
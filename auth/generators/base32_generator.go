@@ -2,22 +2,51 @@ package generators
 
 import (
 	"encoding/base32"
+	"strings"
 
 	"github.com/lukeshay/g/auth"
 )
 
+// lowerBase32Encoding is the lowercase base32 alphabet without padding
+// recommended by The Copenhagen Book for session IDs.
+var lowerBase32Encoding = base32.NewEncoding(strings.ToLower("ABCDEFGHIJKLMNOPQRSTUVWXYZ234567")).WithPadding(base32.NoPadding)
+
 type Base32Generator struct {
-	base auth.Generator
+	base *baseGenerator
 }
 
+var _ auth.EntropyGenerator = &Base32Generator{}
+
 // NewBase32Generator creates a new base32 generator. This is used to generate
-// random strings, such as session IDs.
+// random strings, such as session IDs. The strings are padded with "=" when the
+// number of bytes is not a multiple of 5.
 func NewBase32Generator(bytes uint) auth.Generator {
 	return &Base32Generator{
 		base: newBaseGenerator(bytes, base32.StdEncoding.EncodeToString),
 	}
 }
 
+// NewBase32RawGenerator creates a new base32 generator that does not pad the
+// strings. The strings are safe to use in cookies and URLs.
+func NewBase32RawGenerator(bytes uint) auth.Generator {
+	return &Base32Generator{
+		base: newBaseGenerator(bytes, base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString),
+	}
+}
+
+// NewBase32LowerGenerator creates a new base32 generator that uses the
+// lowercase alphabet without padding, as recommended by The Copenhagen Book.
+// The strings are safe to use in cookies and URLs.
+func NewBase32LowerGenerator(bytes uint) auth.Generator {
+	return &Base32Generator{
+		base: newBaseGenerator(bytes, lowerBase32Encoding.EncodeToString),
+	}
+}
+
 func (g *Base32Generator) Generate() (string, error) {
 	return g.base.Generate()
 }
+
+func (g *Base32Generator) EntropyBits() uint {
+	return g.base.EntropyBits()
+}
@@ -7,17 +7,32 @@ import (
 )
 
 type Base64Generator struct {
-	base auth.Generator
+	base *baseGenerator
 }
 
+var _ auth.EntropyGenerator = &Base64Generator{}
+
 // NewBase64Generator creates a new base64 generator. This is used to generate
-// random strings, such as session IDs.
+// random strings, such as session IDs. The strings can contain "+", "/", and
+// "=", which have to be escaped in URLs.
 func NewBase64Generator(bytes uint) auth.Generator {
 	return &Base64Generator{
 		base: newBaseGenerator(bytes, base64.StdEncoding.EncodeToString),
 	}
 }
 
+// NewBase64URLGenerator creates a new base64 generator that uses the URL-safe
+// alphabet without padding. The strings are safe to use in cookies and URLs.
+func NewBase64URLGenerator(bytes uint) auth.Generator {
+	return &Base64Generator{
+		base: newBaseGenerator(bytes, base64.RawURLEncoding.EncodeToString),
+	}
+}
+
 func (g *Base64Generator) Generate() (string, error) {
 	return g.base.Generate()
 }
+
+func (g *Base64Generator) EntropyBits() uint {
+	return g.base.EntropyBits()
+}
@@ -2,8 +2,7 @@ package generators
 
 import (
 	"crypto/rand"
-
-	"github.com/lukeshay/g/auth"
+	"fmt"
 )
 
 type baseGenerator struct {
@@ -11,7 +10,7 @@ type baseGenerator struct {
 	bytes   uint
 }
 
-func newBaseGenerator(bytes uint, encoder func([]byte) string) auth.Generator {
+func newBaseGenerator(bytes uint, encoder func([]byte) string) *baseGenerator {
 	return &baseGenerator{
 		encoder: encoder,
 		bytes:   bytes,
@@ -20,8 +19,17 @@ func newBaseGenerator(bytes uint, encoder func([]byte) string) auth.Generator {
 
 func (g *baseGenerator) Generate() (string, error) {
 	sessionIdBytes := make([]byte, g.bytes)
-	rand.Read(sessionIdBytes)
+
+	_, err := rand.Read(sessionIdBytes)
+	if err != nil {
+		return "", fmt.Errorf("error reading random bytes: %w", err)
+	}
+
 	value := g.encoder(sessionIdBytes)
 
 	return value, nil
 }
+
+func (g *baseGenerator) EntropyBits() uint {
+	return g.bytes * 8
+}
@@ -0,0 +1,34 @@
+package generators
+
+import (
+	"github.com/lukeshay/g/auth"
+)
+
+// crockfordAlphabet is Crockford's base32 alphabet. It leaves out I, L, O,
+// and U so that codes are easy to read and type.
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+type CrockfordGenerator struct {
+	base *alphabetGenerator
+}
+
+var _ auth.EntropyGenerator = &CrockfordGenerator{}
+
+// NewCrockfordGenerator creates a new generator for human friendly codes using
+// Crockford's base32 alphabet, such as recovery codes. The characters are
+// split into groups of groupSize separated by "-", for example ABCD-EFGH. The
+// length does not include the separators. Set groupSize to 0 to not group the
+// characters.
+func NewCrockfordGenerator(length uint, groupSize uint) auth.Generator {
+	return &CrockfordGenerator{
+		base: newAlphabetGenerator(crockfordAlphabet, length, groupSize, "-"),
+	}
+}
+
+func (g *CrockfordGenerator) Generate() (string, error) {
+	return g.base.Generate()
+}
+
+func (g *CrockfordGenerator) EntropyBits() uint {
+	return g.base.EntropyBits()
+}
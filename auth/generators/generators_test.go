@@ -0,0 +1,116 @@
+package generators_test
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/generators"
+)
+
+func TestByteGenerators(t *testing.T) {
+	lowerBase32 := base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+	tests := map[string]struct {
+		generator auth.Generator
+		length    int
+		alphabet  string
+		decode    func(string) ([]byte, error)
+	}{
+		"Base32": {
+			generator: generators.NewBase32Generator(16),
+			length:    32,
+			alphabet:  "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567=",
+			decode:    base32.StdEncoding.DecodeString,
+		},
+		"Base32Raw": {
+			generator: generators.NewBase32RawGenerator(16),
+			length:    26,
+			alphabet:  "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567",
+			decode:    base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString,
+		},
+		"Base32Lower": {
+			generator: generators.NewBase32LowerGenerator(15),
+			length:    24,
+			alphabet:  "abcdefghijklmnopqrstuvwxyz234567",
+			decode:    lowerBase32.DecodeString,
+		},
+		"Base64": {
+			generator: generators.NewBase64Generator(16),
+			length:    24,
+			alphabet:  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=",
+			decode:    base64.StdEncoding.DecodeString,
+		},
+		"Base64URL": {
+			generator: generators.NewBase64URLGenerator(16),
+			length:    22,
+			alphabet:  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_",
+			decode:    base64.RawURLEncoding.DecodeString,
+		},
+		"Hex": {
+			generator: generators.NewHexGenerator(16),
+			length:    32,
+			alphabet:  "0123456789abcdef",
+			decode:    hex.DecodeString,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			seen := map[string]bool{}
+
+			for i := 0; i < 100; i++ {
+				value, err := test.generator.Generate()
+				if err != nil {
+					t.Fatalf("error generating: %v", err)
+				}
+
+				if len(value) != test.length {
+					t.Fatalf("expected length %d, got %q", test.length, value)
+				}
+
+				if strings.Trim(value, test.alphabet) != "" {
+					t.Fatalf("expected only characters from %q, got %q", test.alphabet, value)
+				}
+
+				decoded, err := test.decode(value)
+				if err != nil {
+					t.Fatalf("error decoding %q: %v", value, err)
+				}
+
+				if uint(len(decoded))*8 != test.generator.(auth.EntropyGenerator).EntropyBits() {
+					t.Fatalf("expected %d random bits, decoded %d bytes", test.generator.(auth.EntropyGenerator).EntropyBits(), len(decoded))
+				}
+
+				if seen[value] {
+					t.Fatalf("generated %q twice", value)
+				}
+
+				seen[value] = true
+			}
+		})
+	}
+}
+
+func TestEntropyBits(t *testing.T) {
+	tests := map[string]struct {
+		generator auth.Generator
+		bits      uint
+	}{
+		"Base32Lower": {generator: generators.NewBase32LowerGenerator(15), bits: 120},
+		"Base64URL":   {generator: generators.NewBase64URLGenerator(32), bits: 256},
+		"Hex":         {generator: generators.NewHexGenerator(8), bits: 64},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bits := test.generator.(auth.EntropyGenerator).EntropyBits()
+			if bits != test.bits {
+				t.Fatalf("expected %d bits, got %d", test.bits, bits)
+			}
+		})
+	}
+}
@@ -7,9 +7,11 @@ import (
 )
 
 type HexGenerator struct {
-	base auth.Generator
+	base *baseGenerator
 }
 
+var _ auth.EntropyGenerator = &HexGenerator{}
+
 // NewHexGenerator creates a new hex generator. This is used to generate
 // random strings, such as session IDs.
 func NewHexGenerator(bytes uint) auth.Generator {
@@ -21,3 +23,7 @@ func NewHexGenerator(bytes uint) auth.Generator {
 func (g *HexGenerator) Generate() (string, error) {
 	return g.base.Generate()
 }
+
+func (g *HexGenerator) EntropyBits() uint {
+	return g.base.EntropyBits()
+}
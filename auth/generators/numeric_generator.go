@@ -0,0 +1,29 @@
+package generators
+
+import (
+	"github.com/lukeshay/g/auth"
+)
+
+type NumericGenerator struct {
+	base *alphabetGenerator
+}
+
+var _ auth.EntropyGenerator = &NumericGenerator{}
+
+// NewNumericGenerator creates a new generator for numeric codes with the given
+// number of digits, such as 6 digit email verification codes. Every digit is
+// equally likely and codes can start with 0. These codes have too little
+// entropy to be used as session IDs.
+func NewNumericGenerator(digits uint) auth.Generator {
+	return &NumericGenerator{
+		base: newAlphabetGenerator("0123456789", digits, 0, ""),
+	}
+}
+
+func (g *NumericGenerator) Generate() (string, error) {
+	return g.base.Generate()
+}
+
+func (g *NumericGenerator) EntropyBits() uint {
+	return g.base.EntropyBits()
+}
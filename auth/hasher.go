@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Hasher is responsible for hashing session IDs before they are stored. The
+// SessionService only hands hashed session IDs to the SessionAdapter so that a
+// leaked sessions table does not contain any valid session IDs. The raw session
+// ID is only ever stored in the cookie. This is outlined in The Copenhagen Book.
+type Hasher interface {
+	// Hash returns the hash of the given session ID. The same input must always
+	// produce the same output and the output should be safe to store as a
+	// string, such as hex.
+	Hash(string) string
+}
+
+// Sha256Hasher is an implementation of the Hasher interface that hashes values
+// with SHA-256 and hex encodes the result. This is the default Hasher used by
+// the SessionService.
+type Sha256Hasher struct{}
+
+// NewSha256Hasher returns a new instance of Sha256Hasher.
+func NewSha256Hasher() Hasher {
+	return &Sha256Hasher{}
+}
+
+func (h *Sha256Hasher) Hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:])
+}
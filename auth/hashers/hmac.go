@@ -0,0 +1,32 @@
+package hashers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/lukeshay/g/auth"
+)
+
+// HmacHasher is an implementation of the Hasher interface that hashes values
+// with HMAC-SHA256 and hex encodes the result. Unlike auth.Sha256Hasher, the
+// stored hashes cannot be checked against a guessed session ID without the
+// secret.
+type HmacHasher struct {
+	secret []byte
+}
+
+// NewHmacHasher returns a new instance of HmacHasher that uses the given secret
+// as the HMAC key.
+func NewHmacHasher(secret string) auth.Hasher {
+	return &HmacHasher{
+		secret: []byte(secret),
+	}
+}
+
+func (h *HmacHasher) Hash(value string) string {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,41 @@
+// Package sqlmigrate runs the schema migrations of the bun adapters.
+package sqlmigrate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+// Run applies all of the migrations that have not been applied to the
+// database yet. The applied migrations are tracked in tableName and
+// locksTableName makes sure only one process runs them at a time.
+func Run(ctx context.Context, db *bun.DB, migrations *migrate.Migrations, tableName string, locksTableName string) error {
+	migrator := migrate.NewMigrator(
+		db,
+		migrations,
+		migrate.WithTableName(tableName),
+		migrate.WithLocksTableName(locksTableName),
+		migrate.WithMarkAppliedOnSuccess(true),
+	)
+
+	err := migrator.Init(ctx)
+	if err != nil {
+		return fmt.Errorf("error initializing migrations: %w", err)
+	}
+
+	err = migrator.Lock(ctx)
+	if err != nil {
+		return fmt.Errorf("error locking migrations: %w", err)
+	}
+	defer migrator.Unlock(ctx)
+
+	_, err = migrator.Migrate(ctx)
+	if err != nil {
+		return fmt.Errorf("error running migrations: %w", err)
+	}
+
+	return nil
+}
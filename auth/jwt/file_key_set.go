@@ -0,0 +1,84 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileKeySet is a KeySet that reads a JSON Web Key Set from a local file. The
+// file is read again when a token is signed with an unknown key ID and the
+// file has changed, so keys can be rotated by replacing the file.
+type FileKeySet struct {
+	path string
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	modTime time.Time
+}
+
+var _ KeySet = &FileKeySet{}
+
+// NewFileKeySet returns a new instance of FileKeySet. The file is read right
+// away so that a missing or invalid file is reported on startup.
+func NewFileKeySet(path string) (KeySet, error) {
+	s := &FileKeySet{
+		path: path,
+	}
+
+	err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileKeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, found := lookup(s.keys, kid); found {
+		return key, nil
+	}
+
+	info, err := os.Stat(s.path)
+	if err == nil && !info.ModTime().Equal(s.modTime) {
+		err = s.load()
+		if err != nil {
+			return nil, err
+		}
+
+		if key, found := lookup(s.keys, kid); found {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (s *FileKeySet) load() error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("error opening jwks file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("error opening jwks file: %w", err)
+	}
+
+	keys, err := parseKeySet(file)
+	if err != nil {
+		return err
+	}
+
+	s.keys = keys
+	s.modTime = info.ModTime()
+
+	return nil
+}
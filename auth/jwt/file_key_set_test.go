@@ -0,0 +1,71 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth/jwt"
+)
+
+func writeKeySet(t *testing.T, path string, keys map[string]*rsa.PrivateKey, modTime time.Time) {
+	t.Helper()
+
+	err := os.WriteFile(path, encodeKeySet(keys), 0o600)
+	if err != nil {
+		t.Fatalf("error writing jwks file: %v", err)
+	}
+
+	err = os.Chtimes(path, modTime, modTime)
+	if err != nil {
+		t.Fatalf("error setting jwks file modtime: %v", err)
+	}
+}
+
+func TestFileKeySetReloadsOnModTime(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	first, second := newKey(t, 2048), newKey(t, 2048)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	writeKeySet(t, path, map[string]*rsa.PrivateKey{"first": first}, modTime)
+
+	keys, err := jwt.NewFileKeySet(path)
+	if err != nil {
+		t.Fatalf("error creating file key set: %v", err)
+	}
+
+	_, err = keys.Key(ctx, "first")
+	if err != nil {
+		t.Fatalf("error getting key: %v", err)
+	}
+
+	// The file is only read again when its modtime changes.
+	writeKeySet(t, path, map[string]*rsa.PrivateKey{"first": first, "second": second}, modTime)
+
+	_, err = keys.Key(ctx, "second")
+	if err == nil {
+		t.Fatal("expected the file not to be read again when its modtime did not change")
+	}
+
+	writeKeySet(t, path, map[string]*rsa.PrivateKey{"first": first, "second": second}, modTime.Add(time.Minute))
+
+	key, err := keys.Key(ctx, "second")
+	if err != nil {
+		t.Fatalf("error getting key after the file changed: %v", err)
+	}
+
+	if !second.PublicKey.Equal(key) {
+		t.Fatal("expected the new key")
+	}
+}
+
+func TestNewFileKeySetMissingFile(t *testing.T) {
+	_, err := jwt.NewFileKeySet(filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,267 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned when a token cannot be verified.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired is returned with ErrInvalidToken when a token has
+	// expired.
+	ErrTokenExpired = errors.New("token is expired")
+)
+
+// DefaultAlgorithms are the signature algorithms a Verifier accepts by
+// default.
+var DefaultAlgorithms = []string{"RS256", "ES256", "EdDSA"}
+
+// Claims are the claims of a verified token.
+type Claims map[string]any
+
+// String returns the claim with the given name or "" when it is missing or not
+// a string.
+func (c Claims) String(name string) string {
+	value, _ := c[name].(string)
+
+	return value
+}
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	return c.String("sub")
+}
+
+// Issuer returns the "iss" claim.
+func (c Claims) Issuer() string {
+	return c.String("iss")
+}
+
+// Email returns the "email" claim.
+func (c Claims) Email() string {
+	return c.String("email")
+}
+
+// EmailVerified returns the "email_verified" claim. Only trust Email when it
+// is true.
+func (c Claims) EmailVerified() bool {
+	value, _ := c["email_verified"].(bool)
+
+	return value
+}
+
+// Audience returns the "aud" claim, which can be a string or an array.
+func (c Claims) Audience() []string {
+	switch value := c["aud"].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		audience := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				audience = append(audience, s)
+			}
+		}
+
+		return audience
+	default:
+		return nil
+	}
+}
+
+// Time returns a NumericDate claim such as "exp" and whether it is present.
+func (c Claims) Time(name string) (time.Time, bool) {
+	var seconds float64
+
+	switch value := c[name].(type) {
+	case json.Number:
+		var err error
+
+		seconds, err = value.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+	case float64:
+		seconds = value
+	default:
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(seconds), 0), true
+}
+
+// Verifier verifies the signature and the registered claims of JWTs.
+type Verifier struct {
+	keySet            KeySet
+	issuer            string
+	audience          string
+	skipIssuerCheck   bool
+	skipAudienceCheck bool
+	algorithms        []string
+	clockSkew         time.Duration
+	now               func() time.Time
+}
+
+type NewVerifierOptions struct {
+	// KeySet holds the keys tokens are signed with.
+	KeySet KeySet
+	// Issuer must be the "iss" claim. It is required unless
+	// SkipIssuerCheck is set.
+	Issuer string
+	// SkipIssuerCheck disables the "iss" check. Only set it when the keys are
+	// not shared with any other issuer.
+	SkipIssuerCheck bool
+	// Audience must be one of the values of the "aud" claim. It is required
+	// unless SkipAudienceCheck is set.
+	Audience string
+	// SkipAudienceCheck disables the "aud" check. Only set it when the keys
+	// are not shared with any other audience.
+	SkipAudienceCheck bool
+	// Algorithms are the accepted signature algorithms. Defaults to
+	// DefaultAlgorithms.
+	Algorithms []string
+	// ClockSkew is how much the clocks of the issuer and this server may
+	// differ when "exp" and "nbf" are checked. Defaults to 1 minute.
+	ClockSkew time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewVerifier returns a new instance of Verifier. It returns an error when
+// Issuer or Audience is empty and its check is not explicitly skipped.
+func NewVerifier(options NewVerifierOptions) (*Verifier, error) {
+	if options.Issuer == "" && !options.SkipIssuerCheck {
+		return nil, errors.New("issuer is required unless SkipIssuerCheck is set")
+	}
+
+	if options.Audience == "" && !options.SkipAudienceCheck {
+		return nil, errors.New("audience is required unless SkipAudienceCheck is set")
+	}
+
+	v := &Verifier{
+		keySet:            options.KeySet,
+		issuer:            options.Issuer,
+		audience:          options.Audience,
+		skipIssuerCheck:   options.SkipIssuerCheck,
+		skipAudienceCheck: options.SkipAudienceCheck,
+		algorithms:        options.Algorithms,
+		clockSkew:         options.ClockSkew,
+		now:               options.Now,
+	}
+
+	if v.algorithms == nil {
+		v.algorithms = DefaultAlgorithms
+	}
+
+	if v.clockSkew == 0 {
+		v.clockSkew = time.Minute
+	}
+
+	if v.now == nil {
+		v.now = time.Now
+	}
+
+	return v, nil
+}
+
+// Verify verifies the token's signature against the KeySet and checks its
+// "iss", "aud", "exp", and "nbf" claims. Tokens without an "exp" claim are
+// rejected.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if !slices.Contains(v.algorithms, header.Alg) {
+		return nil, fmt.Errorf("%w: algorithm %q is not allowed", ErrInvalidToken, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	key, err := v.keySet.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	err = verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	claims := Claims{}
+
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	err = v.validateClaims(claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) validateClaims(claims Claims) error {
+	now := v.now()
+
+	if !v.skipIssuerCheck && claims.Issuer() != v.issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer())
+	}
+
+	if !v.skipAudienceCheck && !slices.Contains(claims.Audience(), v.audience) {
+		return fmt.Errorf("token is not for audience %q", v.audience)
+	}
+
+	expiresAt, ok := claims.Time("exp")
+	if !ok {
+		return errors.New("missing exp claim")
+	}
+
+	if now.After(expiresAt.Add(v.clockSkew)) {
+		return fmt.Errorf("%w: %s", ErrTokenExpired, expiresAt.Format(time.RFC3339))
+	}
+
+	if notBefore, ok := claims.Time("nbf"); ok && now.Add(v.clockSkew).Before(notBefore) {
+		return fmt.Errorf("token is not valid until %s", notBefore.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a JWT. Numbers
+// are decoded as json.Number so that NumericDate claims keep their precision.
+func decodeSegment(segment string, v any) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+
+	return decoder.Decode(v)
+}
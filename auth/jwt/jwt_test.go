@@ -0,0 +1,198 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth/jwt"
+)
+
+// keySet is a KeySet with fixed keys.
+type keySet map[string]crypto.PublicKey
+
+func (s keySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+func newKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("error generating rsa key: %v", err)
+	}
+
+	return key
+}
+
+// encodeKeySet returns the JSON Web Key Set of the public keys of the given
+// keys.
+func encodeKeySet(keys map[string]*rsa.PrivateKey) []byte {
+	b64 := base64.RawURLEncoding
+	jwks := []map[string]any{}
+
+	for kid, key := range keys {
+		jwks = append(jwks, map[string]any{
+			"kty": "RSA",
+			"kid": kid,
+			"n":   b64.EncodeToString(key.N.Bytes()),
+			"e":   b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	body, _ := json.Marshal(map[string]any{"keys": jwks})
+
+	return body
+}
+
+func sign(key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	b64 := base64.RawURLEncoding
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, _ := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+
+	return signingInput + "." + b64.EncodeToString(signature)
+}
+
+func newVerifier(t *testing.T, keys jwt.KeySet, now time.Time) *jwt.Verifier {
+	t.Helper()
+
+	verifier, err := jwt.NewVerifier(jwt.NewVerifierOptions{
+		KeySet:   keys,
+		Issuer:   "issuer",
+		Audience: "audience",
+		Now:      func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("error creating verifier: %v", err)
+	}
+
+	return verifier
+}
+
+func TestVerify(t *testing.T) {
+	key := newKey(t, 2048)
+	now := time.Now()
+	verifier := newVerifier(t, keySet{"a": key.Public()}, now)
+
+	claims, err := verifier.Verify(context.Background(), sign(key, "a", map[string]any{
+		"iss": "issuer",
+		"aud": []string{"other", "audience"},
+		"sub": "user",
+		"exp": now.Add(time.Hour).Unix(),
+	}))
+	if err != nil {
+		t.Fatalf("error verifying token: %v", err)
+	}
+
+	if claims.Subject() != "user" {
+		t.Fatalf("expected subject user, got %q", claims.Subject())
+	}
+
+	tests := map[string]map[string]any{
+		"wrong issuer":   {"iss": "other", "aud": "audience", "exp": now.Add(time.Hour).Unix()},
+		"wrong audience": {"iss": "issuer", "aud": "other", "exp": now.Add(time.Hour).Unix()},
+		"missing exp":    {"iss": "issuer", "aud": "audience"},
+	}
+
+	for name, claims := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), sign(key, "a", claims))
+			if !errors.Is(err, jwt.ErrInvalidToken) {
+				t.Fatalf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyClockSkew(t *testing.T) {
+	key := newKey(t, 2048)
+	now := time.Now()
+	verifier := newVerifier(t, keySet{"a": key.Public()}, now)
+
+	tests := []struct {
+		name    string
+		claims  map[string]any
+		wantErr error
+	}{
+		{
+			name:   "expired within the clock skew",
+			claims: map[string]any{"exp": now.Add(-30 * time.Second).Unix()},
+		},
+		{
+			name:    "expired beyond the clock skew",
+			claims:  map[string]any{"exp": now.Add(-2 * time.Minute).Unix()},
+			wantErr: jwt.ErrTokenExpired,
+		},
+		{
+			name:   "not yet valid within the clock skew",
+			claims: map[string]any{"exp": now.Add(time.Hour).Unix(), "nbf": now.Add(30 * time.Second).Unix()},
+		},
+		{
+			name:    "not yet valid beyond the clock skew",
+			claims:  map[string]any{"exp": now.Add(time.Hour).Unix(), "nbf": now.Add(2 * time.Minute).Unix()},
+			wantErr: jwt.ErrInvalidToken,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.claims["iss"] = "issuer"
+			test.claims["aud"] = "audience"
+
+			_, err := verifier.Verify(context.Background(), sign(key, "a", test.claims))
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("error verifying token: %v", err)
+			} else if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsSmallRSAKeys(t *testing.T) {
+	key := newKey(t, 1024)
+	now := time.Now()
+	verifier := newVerifier(t, keySet{"a": key.Public()}, now)
+
+	_, err := verifier.Verify(context.Background(), sign(key, "a", map[string]any{
+		"iss": "issuer",
+		"aud": "audience",
+		"exp": now.Add(time.Hour).Unix(),
+	}))
+	if !errors.Is(err, jwt.ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestVerifyRejectsUnexpectedAlgorithms(t *testing.T) {
+	key := newKey(t, 2048)
+	now := time.Now()
+	verifier := newVerifier(t, keySet{"a": key.Public()}, now)
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"a"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"iss":"issuer","aud":"audience","exp":%d}`, now.Add(time.Hour).Unix())))
+
+	_, err := verifier.Verify(context.Background(), header+"."+payload+".")
+	if !errors.Is(err, jwt.ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+}
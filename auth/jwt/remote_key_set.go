@@ -0,0 +1,194 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RemoteKeySet is a KeySet that fetches a JSON Web Key Set from a URL. The
+// keys are cached and fetched again every RefreshInterval, or sooner when a
+// token is signed with an unknown key ID, such as after the issuer rotates its
+// keys. The cached keys keep being used when the URL cannot be reached. Only
+// one fetch runs at a time and it does not block lookups of cached keys.
+type RemoteKeySet struct {
+	url                string
+	httpClient         *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	fetchTimeout       time.Duration
+	now                func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// fetching is closed when the running fetch finishes. It is nil when no
+	// fetch is running.
+	fetching chan struct{}
+	fetchErr error
+}
+
+var _ KeySet = &RemoteKeySet{}
+
+type NewRemoteKeySetOptions struct {
+	// URL is the URL of the JSON Web Key Set.
+	URL string
+	// HTTPClient is used to fetch the keys. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// RefreshInterval is how long the keys are cached for. Defaults to 1
+	// hour.
+	RefreshInterval time.Duration
+	// MinRefreshInterval is the minimum time between two fetches, so that
+	// tokens with unknown key IDs cannot be used to flood the URL. Defaults
+	// to 1 minute.
+	MinRefreshInterval time.Duration
+	// FetchTimeout is how long a fetch may take. Fetches are shared by all of
+	// the tokens waiting for them, so they are not cancelled with the context
+	// of the token that started them. Defaults to 10 seconds.
+	FetchTimeout time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewRemoteKeySet returns a new instance of RemoteKeySet. The keys are fetched
+// when they are first used.
+func NewRemoteKeySet(options NewRemoteKeySetOptions) KeySet {
+	s := &RemoteKeySet{
+		url:                options.URL,
+		httpClient:         options.HTTPClient,
+		refreshInterval:    options.RefreshInterval,
+		minRefreshInterval: options.MinRefreshInterval,
+		fetchTimeout:       options.FetchTimeout,
+		now:                options.Now,
+		keys:               map[string]crypto.PublicKey{},
+	}
+
+	if s.httpClient == nil {
+		s.httpClient = http.DefaultClient
+	}
+
+	if s.refreshInterval == 0 {
+		s.refreshInterval = time.Hour
+	}
+
+	if s.minRefreshInterval == 0 {
+		s.minRefreshInterval = time.Minute
+	}
+
+	if s.fetchTimeout == 0 {
+		s.fetchTimeout = 10 * time.Second
+	}
+
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	return s
+}
+
+func (s *RemoteKeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+
+	now := s.now()
+
+	key, found := lookup(s.keys, kid)
+	if found && now.Sub(s.fetchedAt) < s.refreshInterval {
+		s.mu.Unlock()
+
+		return key, nil
+	}
+
+	if s.fetching == nil {
+		if !s.attemptedAt.IsZero() && now.Sub(s.attemptedAt) < s.minRefreshInterval {
+			s.mu.Unlock()
+
+			if found {
+				return key, nil
+			}
+
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+
+		s.attemptedAt = now
+		s.fetching = make(chan struct{})
+
+		go s.refresh(context.WithoutCancel(ctx), now, s.fetching)
+	}
+
+	fetching := s.fetching
+
+	s.mu.Unlock()
+
+	select {
+	case <-fetching:
+	case <-ctx.Done():
+		if found {
+			return key, nil
+		}
+
+		return nil, fmt.Errorf("error waiting for jwks: %w", ctx.Err())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The previous keys are kept when the fetch fails, so a key that was
+	// found before is still found.
+	key, found = lookup(s.keys, kid)
+	if found {
+		return key, nil
+	}
+
+	if s.fetchErr != nil {
+		return nil, s.fetchErr
+	}
+
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// refresh fetches the keys without holding the lock and closes done when the
+// keys have been updated.
+func (s *RemoteKeySet) refresh(ctx context.Context, attemptedAt time.Time, done chan struct{}) {
+	ctx, cancel := context.WithTimeout(ctx, s.fetchTimeout)
+	defer cancel()
+
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		s.keys = keys
+		s.fetchedAt = attemptedAt
+	}
+
+	s.fetchErr = err
+	s.fetching = nil
+
+	close(done)
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching jwks: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching jwks: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching jwks: unexpected status %d", res.StatusCode)
+	}
+
+	return parseKeySet(res.Body)
+}
@@ -0,0 +1,253 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth/jwt"
+)
+
+// jwksServer serves a JSON Web Key Set that can be changed while the test
+// runs.
+type jwksServer struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*rsa.PrivateKey
+	status   int
+	requests int
+	// block, when set, holds requests until it is closed. started receives a
+	// value for every request that is held.
+	block   chan struct{}
+	started chan struct{}
+}
+
+func newJWKSServer(t *testing.T, keys map[string]*rsa.PrivateKey) *jwksServer {
+	t.Helper()
+
+	s := &jwksServer{keys: keys, status: http.StatusOK}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+func (s *jwksServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	block, started := s.block, s.started
+	s.mu.Unlock()
+
+	if block != nil {
+		started <- struct{}{}
+		<-block
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.WriteHeader(s.status)
+	w.Write(encodeKeySet(s.keys))
+}
+
+func (s *jwksServer) set(keys map[string]*rsa.PrivateKey, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = keys
+	s.status = status
+}
+
+func (s *jwksServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// clock is a Now function that only moves when it is advanced.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newRemoteKeySet(server *jwksServer, c *clock) jwt.KeySet {
+	return jwt.NewRemoteKeySet(jwt.NewRemoteKeySetOptions{
+		URL:                server.server.URL,
+		RefreshInterval:    time.Hour,
+		MinRefreshInterval: time.Minute,
+		Now:                c.Now,
+	})
+}
+
+func TestRemoteKeySetRotation(t *testing.T) {
+	ctx := context.Background()
+	first, second := newKey(t, 2048), newKey(t, 2048)
+	server := newJWKSServer(t, map[string]*rsa.PrivateKey{"first": first})
+	c := &clock{now: time.Now()}
+	keys := newRemoteKeySet(server, c)
+
+	_, err := keys.Key(ctx, "first")
+	if err != nil {
+		t.Fatalf("error getting key: %v", err)
+	}
+
+	_, err = keys.Key(ctx, "first")
+	if err != nil {
+		t.Fatalf("error getting key: %v", err)
+	}
+
+	if server.requestCount() != 1 {
+		t.Fatalf("expected cached keys to be used, got %d requests", server.requestCount())
+	}
+
+	server.set(map[string]*rsa.PrivateKey{"second": second}, http.StatusOK)
+	c.now = c.now.Add(2 * time.Minute)
+
+	key, err := keys.Key(ctx, "second")
+	if err != nil {
+		t.Fatalf("error getting rotated key: %v", err)
+	}
+
+	if !second.PublicKey.Equal(key) {
+		t.Fatal("expected the rotated key")
+	}
+
+	if server.requestCount() != 2 {
+		t.Fatalf("expected an unknown key id to refetch the keys, got %d requests", server.requestCount())
+	}
+}
+
+func TestRemoteKeySetMinRefreshInterval(t *testing.T) {
+	ctx := context.Background()
+	server := newJWKSServer(t, map[string]*rsa.PrivateKey{"first": newKey(t, 2048)})
+	c := &clock{now: time.Now()}
+	keys := newRemoteKeySet(server, c)
+
+	_, err := keys.Key(ctx, "first")
+	if err != nil {
+		t.Fatalf("error getting key: %v", err)
+	}
+
+	for range 3 {
+		_, err = keys.Key(ctx, "unknown")
+		if err == nil {
+			t.Fatal("expected an error for an unknown key id")
+		}
+	}
+
+	if server.requestCount() != 1 {
+		t.Fatalf("expected unknown key ids not to refetch within the min refresh interval, got %d requests", server.requestCount())
+	}
+
+	c.now = c.now.Add(2 * time.Minute)
+
+	_, err = keys.Key(ctx, "unknown")
+	if err == nil {
+		t.Fatal("expected an error for an unknown key id")
+	}
+
+	if server.requestCount() != 2 {
+		t.Fatalf("expected the keys to be refetched after the min refresh interval, got %d requests", server.requestCount())
+	}
+}
+
+func TestRemoteKeySetKeepsStaleKeys(t *testing.T) {
+	ctx := context.Background()
+	first := newKey(t, 2048)
+	server := newJWKSServer(t, map[string]*rsa.PrivateKey{"first": first})
+	c := &clock{now: time.Now()}
+	keys := newRemoteKeySet(server, c)
+
+	_, err := keys.Key(ctx, "first")
+	if err != nil {
+		t.Fatalf("error getting key: %v", err)
+	}
+
+	server.set(nil, http.StatusInternalServerError)
+	c.now = c.now.Add(2 * time.Hour)
+
+	key, err := keys.Key(ctx, "first")
+	if err != nil {
+		t.Fatalf("expected the stale key when the keys cannot be fetched, got %v", err)
+	}
+
+	if !first.PublicKey.Equal(key) {
+		t.Fatal("expected the stale key")
+	}
+
+	if server.requestCount() != 2 {
+		t.Fatalf("expected expired keys to be refetched, got %d requests", server.requestCount())
+	}
+
+	c.now = c.now.Add(2 * time.Minute)
+
+	_, err = keys.Key(ctx, "unknown")
+	if err == nil {
+		t.Fatal("expected the fetch error for an unknown key id")
+	}
+}
+
+func TestRemoteKeySetFetchDoesNotBlockCachedKeys(t *testing.T) {
+	first, second := newKey(t, 2048), newKey(t, 2048)
+	server := newJWKSServer(t, map[string]*rsa.PrivateKey{"first": first})
+	c := &clock{now: time.Now()}
+	keys := newRemoteKeySet(server, c)
+
+	_, err := keys.Key(context.Background(), "first")
+	if err != nil {
+		t.Fatalf("error getting key: %v", err)
+	}
+
+	block := make(chan struct{})
+	server.mu.Lock()
+	server.block, server.started = block, make(chan struct{}, 1)
+	server.mu.Unlock()
+
+	c.now = c.now.Add(2 * time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+
+	go func() {
+		_, err := keys.Key(ctx, "second")
+		errs <- err
+	}()
+
+	<-server.started
+
+	_, err = keys.Key(context.Background(), "first")
+	if err != nil {
+		t.Fatalf("error getting cached key during a fetch: %v", err)
+	}
+
+	cancel()
+
+	err = <-errs
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	server.set(map[string]*rsa.PrivateKey{"first": first, "second": second}, http.StatusOK)
+	close(block)
+
+	// The fetch was shared, so cancelling the context that started it must
+	// not cancel it, and its result is used without another request.
+	_, err = keys.Key(context.Background(), "second")
+	if err != nil {
+		t.Fatalf("error getting key from the shared fetch: %v", err)
+	}
+
+	if server.requestCount() != 2 {
+		t.Fatalf("expected 2 requests, got %d", server.requestCount())
+	}
+}
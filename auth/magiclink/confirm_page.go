@@ -0,0 +1,31 @@
+package magiclink
+
+import (
+	"html/template"
+	"io"
+)
+
+// confirmPage asks the user to confirm the sign in. Links are verified with a
+// POST so that link scanners and browsers that prefetch the link with a GET do
+// not use it up.
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Sign in</title>
+</head>
+<body>
+<form method="post">
+<input type="hidden" name="token" value="{{.}}">
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+// WriteConfirmPage writes a page with a form that POSTs the link token as the
+// "token" form value to the page's URL.
+func WriteConfirmPage(w io.Writer, token string) error {
+	return confirmPage.Execute(w, token)
+}
@@ -0,0 +1,83 @@
+package magiclink
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// InMemoryAdapter is an in-memory implementation of the Adapter interface.
+type InMemoryAdapter struct {
+	mu     sync.Mutex
+	tokens map[string]LinkToken
+}
+
+// NewInMemoryAdapter returns a new instance of InMemoryAdapter.
+func NewInMemoryAdapter() Adapter {
+	return &InMemoryAdapter{
+		tokens: map[string]LinkToken{},
+	}
+}
+
+func (a *InMemoryAdapter) InsertToken(ctx context.Context, token LinkToken) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.tokens[token.TokenHash] = token
+
+	return nil
+}
+
+func (a *InMemoryAdapter) UseToken(ctx context.Context, tokenHash string, usedAt time.Time) (LinkToken, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	token, found := a.tokens[tokenHash]
+	if !found {
+		return LinkToken{}, ErrTokenNotFound
+	}
+
+	if token.UsedAt.IsZero() {
+		usedToken := token
+		usedToken.UsedAt = usedAt
+		a.tokens[tokenHash] = usedToken
+	}
+
+	return token, nil
+}
+
+func (a *InMemoryAdapter) GetLatestToken(ctx context.Context, email string) (LinkToken, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var latestToken LinkToken
+	found := false
+
+	for _, token := range a.tokens {
+		if token.Email == email && (!found || token.CreatedAt.After(latestToken.CreatedAt)) {
+			latestToken = token
+			found = true
+		}
+	}
+
+	if !found {
+		return LinkToken{}, ErrTokenNotFound
+	}
+
+	return latestToken, nil
+}
+
+func (a *InMemoryAdapter) DeleteExpiredTokens(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+
+	for tokenHash, token := range a.tokens {
+		if token.ExpiresAt.Before(now) {
+			delete(a.tokens, tokenHash)
+		}
+	}
+
+	return nil
+}
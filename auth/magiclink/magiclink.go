@@ -0,0 +1,259 @@
+package magiclink
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/generators"
+	"github.com/lukeshay/g/auth/verification"
+)
+
+var (
+	// ErrTokenNotFound is returned when a link token does not exist. Adapters
+	// should return this error when a token cannot be found.
+	ErrTokenNotFound = errors.New("magic link token not found")
+	// ErrTokenExpired is returned when a link token exists but has expired.
+	ErrTokenExpired = errors.New("magic link token is expired")
+	// ErrTokenReused is returned when a link token that was already used is
+	// used again. This can mean the link was intercepted.
+	ErrTokenReused = errors.New("magic link token was already used")
+	// ErrBrowserMismatch is returned when a link is opened in a different
+	// browser than the one that requested it. The link cannot be used again.
+	ErrBrowserMismatch = errors.New("magic link was requested from a different browser")
+	// ErrResendThrottled is returned when a browser requests a link before
+	// ResendInterval has passed since it was sent the previous link to the
+	// same email address.
+	ErrResendThrottled = errors.New("magic link was sent too recently")
+)
+
+// DefaultCookieName is the name of the cookie that binds links to the browser
+// that requested them when no other name is configured.
+const DefaultCookieName = "magic_link"
+
+// LinkToken is a one time token that signs the user in to the browser that
+// requested it. Only the hashes of the link token and the browser token are
+// stored.
+type LinkToken struct {
+	TokenHash        string
+	BrowserTokenHash string
+	Email            string
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	// UsedAt is when the token was used. It is zero until then.
+	UsedAt time.Time
+}
+
+// Adapter is responsible for storing link tokens in a datastore of your
+// choice. Used tokens are kept until they expire so that reuse can be
+// detected.
+type Adapter interface {
+	// InsertToken inserts a new token.
+	InsertToken(ctx context.Context, token LinkToken) error
+	// UseToken sets UsedAt on the token with the given hash if it is not set
+	// yet and returns the token as it was before. It must be atomic so that
+	// only one call sees a zero UsedAt. It returns ErrTokenNotFound when the
+	// token does not exist.
+	UseToken(ctx context.Context, tokenHash string, usedAt time.Time) (LinkToken, error)
+	// GetLatestToken returns the most recently created token for the email
+	// address. It returns ErrTokenNotFound when there is none.
+	GetLatestToken(ctx context.Context, email string) (LinkToken, error)
+	// DeleteExpiredTokens deletes all tokens that expired before now.
+	DeleteExpiredTokens(ctx context.Context) error
+}
+
+// Service emails one time sign in links. Each link is bound to the browser
+// that requested it with a browser token that is stored in a short lived
+// cookie, so a link that is intercepted cannot be used from another browser.
+type Service struct {
+	adapter        Adapter
+	mailer         verification.Mailer
+	compose        verification.Compose
+	generator      auth.Generator
+	hasher         auth.Hasher
+	ttl            time.Duration
+	resendInterval time.Duration
+	now            func() time.Time
+}
+
+type NewOptions struct {
+	Adapter Adapter
+	Mailer  verification.Mailer
+	// Compose builds the message sent to the user. It should link to the
+	// handler that verifies the token. Defaults to a message containing just
+	// the token.
+	Compose verification.Compose
+	// Generator generates the link and browser tokens. Defaults to 20 random
+	// bytes encoded as lowercase base32.
+	Generator auth.Generator
+	// Hasher hashes the tokens before they are stored. Defaults to SHA-256.
+	Hasher auth.Hasher
+	// TTL is how long a link is valid for. Defaults to 15 minutes.
+	TTL time.Duration
+	// ResendInterval is the minimum time between two links for the same email
+	// address requested by the same browser. Defaults to 1 minute.
+	ResendInterval time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// New returns a new instance of Service.
+func New(options NewOptions) *Service {
+	s := &Service{
+		adapter:        options.Adapter,
+		mailer:         options.Mailer,
+		compose:        options.Compose,
+		generator:      options.Generator,
+		hasher:         options.Hasher,
+		ttl:            options.TTL,
+		resendInterval: options.ResendInterval,
+		now:            options.Now,
+	}
+
+	if s.compose == nil {
+		s.compose = defaultCompose
+	}
+
+	if s.generator == nil {
+		s.generator = generators.NewBase32LowerGenerator(20)
+	}
+
+	if s.hasher == nil {
+		s.hasher = auth.NewSha256Hasher()
+	}
+
+	if s.ttl == 0 {
+		s.ttl = 15 * time.Minute
+	}
+
+	if s.resendInterval == 0 {
+		s.resendInterval = time.Minute
+	}
+
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	return s
+}
+
+// TTL returns how long a link is valid for.
+func (s *Service) TTL() time.Duration {
+	return s.ttl
+}
+
+// SendLink emails a link token to the email address and returns the browser
+// token. Store the browser token in a cookie that lives for TTL, it must be
+// passed to VerifyLink with the link token. previousBrowserToken is the browser
+// token from the requesting browser's cookie, or empty when it has none. It
+// returns an error wrapping ErrResendThrottled when the same browser was sent
+// a link to the email address less than ResendInterval ago. Links requested by
+// other browsers do not count, so requesting links for someone else's address
+// cannot keep them from signing in.
+func (s *Service) SendLink(ctx context.Context, email string, previousBrowserToken string) (string, error) {
+	now := s.now()
+
+	latestToken, err := s.adapter.GetLatestToken(ctx, email)
+	if err == nil && now.Before(latestToken.CreatedAt.Add(s.resendInterval)) && s.sameBrowser(latestToken, previousBrowserToken) {
+		return "", fmt.Errorf("%w: try again in %s", ErrResendThrottled, latestToken.CreatedAt.Add(s.resendInterval).Sub(now).Round(time.Second))
+	} else if err != nil && !errors.Is(err, ErrTokenNotFound) {
+		return "", fmt.Errorf("error getting latest magic link token: %w", err)
+	}
+
+	token, err := s.generator.Generate()
+	if err != nil {
+		return "", fmt.Errorf("error generating magic link token: %w", err)
+	}
+
+	browserToken, err := s.generator.Generate()
+	if err != nil {
+		return "", fmt.Errorf("error generating magic link browser token: %w", err)
+	}
+
+	err = s.adapter.InsertToken(ctx, LinkToken{
+		TokenHash:        s.hasher.Hash(token),
+		BrowserTokenHash: s.hasher.Hash(browserToken),
+		Email:            email,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(s.ttl),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error inserting magic link token: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, s.compose(email, token))
+	if err != nil {
+		return "", fmt.Errorf("error sending magic link: %w", err)
+	}
+
+	return browserToken, nil
+}
+
+// VerifyLink uses the link token and returns it. The link token is used up
+// even when the browser token does not match so that an intercepted link
+// cannot be tried again. It returns an error wrapping ErrTokenReused when the
+// link token was already used.
+func (s *Service) VerifyLink(ctx context.Context, token string, browserToken string) (LinkToken, error) {
+	now := s.now()
+
+	linkToken, err := s.adapter.UseToken(ctx, s.hasher.Hash(token), now)
+	if err != nil {
+		return LinkToken{}, fmt.Errorf("error using magic link token: %w", err)
+	}
+
+	if !linkToken.UsedAt.IsZero() {
+		return LinkToken{}, fmt.Errorf("%w: %s", ErrTokenReused, linkToken.UsedAt.Format(time.RFC3339))
+	}
+
+	if linkToken.ExpiresAt.Before(now) {
+		return LinkToken{}, fmt.Errorf("%w: %s", ErrTokenExpired, linkToken.ExpiresAt.Format(time.RFC3339))
+	}
+
+	if !s.sameBrowser(linkToken, browserToken) {
+		return LinkToken{}, ErrBrowserMismatch
+	}
+
+	return linkToken, nil
+}
+
+// DeleteExpiredTokens deletes all expired link tokens. Call it periodically to
+// keep the datastore from growing forever.
+func (s *Service) DeleteExpiredTokens(ctx context.Context) error {
+	return s.adapter.DeleteExpiredTokens(ctx)
+}
+
+// ClientError returns the status code and message to respond with for an error
+// returned by SendLink or VerifyLink. ok is false for errors that are not
+// caused by the client, respond with 500 Internal Server Error to those.
+func ClientError(err error) (status int, message string, ok bool) {
+	switch {
+	case errors.Is(err, ErrResendThrottled):
+		return http.StatusTooManyRequests, "magic link was sent too recently", true
+	case errors.Is(err, ErrTokenReused):
+		return http.StatusBadRequest, "magic link was already used", true
+	case errors.Is(err, ErrBrowserMismatch):
+		return http.StatusBadRequest, "magic link must be opened in the browser that requested it", true
+	case errors.Is(err, ErrTokenNotFound) || errors.Is(err, ErrTokenExpired):
+		return http.StatusBadRequest, "magic link is invalid or expired", true
+	default:
+		return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), false
+	}
+}
+
+// sameBrowser compares the hash of the browser token with the token's browser
+// token hash in constant time.
+func (s *Service) sameBrowser(linkToken LinkToken, browserToken string) bool {
+	return browserToken != "" && subtle.ConstantTimeCompare([]byte(linkToken.BrowserTokenHash), []byte(s.hasher.Hash(browserToken))) == 1
+}
+
+func defaultCompose(email string, token string) verification.Message {
+	return verification.Message{
+		To:      email,
+		Subject: "Sign in",
+		Body:    fmt.Sprintf("Your sign in code is %s", token),
+	}
+}
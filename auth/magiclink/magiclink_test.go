@@ -0,0 +1,178 @@
+package magiclink_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth/magiclink"
+	"github.com/lukeshay/g/auth/verification"
+)
+
+type testService struct {
+	*magiclink.Service
+	mailer *verification.InMemoryMailer
+	now    time.Time
+}
+
+func newTestService(t *testing.T) *testService {
+	t.Helper()
+
+	s := &testService{
+		mailer: verification.NewInMemoryMailer(),
+		now:    time.Now(),
+	}
+
+	s.Service = magiclink.New(magiclink.NewOptions{
+		Adapter: magiclink.NewInMemoryAdapter(),
+		Mailer:  s.mailer,
+		Compose: func(email string, token string) verification.Message {
+			return verification.Message{To: email, Body: token}
+		},
+		Now: func() time.Time {
+			return s.now
+		},
+	})
+
+	return s
+}
+
+// sendLink sends a link and returns the emailed link token and the browser
+// token.
+func (s *testService) sendLink(t *testing.T, email string, previousBrowserToken string) (string, string) {
+	t.Helper()
+
+	browserToken, err := s.SendLink(context.Background(), email, previousBrowserToken)
+	if err != nil {
+		t.Fatalf("error sending link: %v", err)
+	}
+
+	messages := s.mailer.Messages()
+
+	return messages[len(messages)-1].Body, browserToken
+}
+
+func TestVerifyLink(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	token, browserToken := s.sendLink(t, "user@example.com", "")
+
+	linkToken, err := s.VerifyLink(ctx, token, browserToken)
+	if err != nil {
+		t.Fatalf("error verifying link: %v", err)
+	}
+
+	if linkToken.Email != "user@example.com" {
+		t.Errorf("unexpected token: %+v", linkToken)
+	}
+
+	_, err = s.VerifyLink(ctx, token, browserToken)
+	if !errors.Is(err, magiclink.ErrTokenReused) {
+		t.Fatalf("expected the link to be single use, got %v", err)
+	}
+
+	_, err = s.VerifyLink(ctx, "unknown", browserToken)
+	if !errors.Is(err, magiclink.ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound, got %v", err)
+	}
+}
+
+func TestVerifyLinkBrowserMismatch(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	token, browserToken := s.sendLink(t, "user@example.com", "")
+
+	_, err := s.VerifyLink(ctx, token, "other")
+	if !errors.Is(err, magiclink.ErrBrowserMismatch) {
+		t.Fatalf("expected ErrBrowserMismatch, got %v", err)
+	}
+
+	// An intercepted link that was tried from another browser cannot be used
+	// afterwards, not even by the browser that requested it.
+	_, err = s.VerifyLink(ctx, token, browserToken)
+	if !errors.Is(err, magiclink.ErrTokenReused) {
+		t.Fatalf("expected ErrTokenReused, got %v", err)
+	}
+
+	token, _ = s.sendLink(t, "other@example.com", "")
+
+	_, err = s.VerifyLink(ctx, token, "")
+	if !errors.Is(err, magiclink.ErrBrowserMismatch) {
+		t.Fatalf("expected ErrBrowserMismatch without a browser token, got %v", err)
+	}
+}
+
+func TestVerifyLinkExpired(t *testing.T) {
+	s := newTestService(t)
+	token, browserToken := s.sendLink(t, "user@example.com", "")
+
+	s.now = s.now.Add(s.TTL() + time.Second)
+
+	_, err := s.VerifyLink(context.Background(), token, browserToken)
+	if !errors.Is(err, magiclink.ErrTokenExpired) {
+		t.Fatalf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestSendLinkThrottle(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	_, browserToken := s.sendLink(t, "user@example.com", "")
+
+	_, err := s.SendLink(ctx, "user@example.com", browserToken)
+	if !errors.Is(err, magiclink.ErrResendThrottled) {
+		t.Fatalf("expected the same browser to be throttled, got %v", err)
+	}
+
+	// Someone else requesting links for the address must not keep its owner
+	// from signing in.
+	s.now = s.now.Add(time.Second)
+	_, attackerBrowserToken := s.sendLink(t, "user@example.com", "")
+
+	s.now = s.now.Add(time.Second)
+	token, ownerBrowserToken := s.sendLink(t, "user@example.com", "")
+
+	_, err = s.SendLink(ctx, "user@example.com", attackerBrowserToken)
+	if err != nil {
+		t.Fatalf("expected a browser that did not send the latest link not to be throttled, got %v", err)
+	}
+
+	_, err = s.VerifyLink(ctx, token, ownerBrowserToken)
+	if err != nil {
+		t.Fatalf("error verifying link: %v", err)
+	}
+
+	_, browserToken = s.sendLink(t, "other@example.com", "")
+
+	s.now = s.now.Add(time.Minute)
+
+	_, err = s.SendLink(ctx, "other@example.com", browserToken)
+	if err != nil {
+		t.Fatalf("expected links to be sent again after the resend interval, got %v", err)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	tests := map[error]int{
+		magiclink.ErrResendThrottled: http.StatusTooManyRequests,
+		magiclink.ErrTokenReused:     http.StatusBadRequest,
+		magiclink.ErrBrowserMismatch: http.StatusBadRequest,
+		magiclink.ErrTokenNotFound:   http.StatusBadRequest,
+		magiclink.ErrTokenExpired:    http.StatusBadRequest,
+	}
+
+	for err, expected := range tests {
+		status, message, ok := magiclink.ClientError(fmt.Errorf("wrapped: %w", err))
+		if !ok || status != expected || message == "" {
+			t.Errorf("expected %d for %v, got %d, %q, %v", expected, err, status, message, ok)
+		}
+	}
+
+	status, message, ok := magiclink.ClientError(errors.New("database is down"))
+	if ok || status != http.StatusInternalServerError || message != http.StatusText(http.StatusInternalServerError) {
+		t.Errorf("expected other errors not to be shown, got %d, %q, %v", status, message, ok)
+	}
+}
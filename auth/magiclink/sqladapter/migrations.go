@@ -0,0 +1,100 @@
+package sqladapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/lukeshay/g/auth/internal/sqlmigrate"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+const (
+	// MigrationsTableName is the table Migrate uses to track which of the
+	// magic link token migrations have been applied.
+	MigrationsTableName = "auth_magic_link_migrations"
+	// MigrationLocksTableName is the table Migrate uses to make sure only one
+	// process runs the magic link token migrations at a time.
+	MigrationLocksTableName = "auth_magic_link_migration_locks"
+)
+
+// Migrations contains the versioned schema migrations for the
+// magic_link_tokens table. Migrations are never changed once released, new
+// versions are appended instead.
+var Migrations = migrate.NewMigrations()
+
+// linkTokenV1 is a snapshot of the magic_link_tokens table when it
+// was first created.
+type linkTokenV1 struct {
+	bun.BaseModel `bun:"table:magic_link_tokens"`
+
+	TokenHash        string    `bun:",pk"`
+	BrowserTokenHash string    `bun:",notnull"`
+	Email            string    `bun:",notnull"`
+	CreatedAt        time.Time `bun:",notnull"`
+	ExpiresAt        time.Time `bun:",notnull"`
+	UsedAt           time.Time `bun:",nullzero"`
+}
+
+func init() {
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000001",
+		Comment: "create_magic_link_tokens_table",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateTable().Model((*linkTokenV1)(nil)).IfNotExists().Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropTable().Model((*linkTokenV1)(nil)).IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000002",
+		Comment: "create_magic_link_tokens_expires_at_index",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateIndex().
+				Model((*linkTokenV1)(nil)).
+				Index("magic_link_tokens_expires_at_idx").
+				Column("expires_at").
+				IfNotExists().
+				Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropIndex().Index("magic_link_tokens_expires_at_idx").IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000003",
+		Comment: "create_magic_link_tokens_email_index",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateIndex().
+				Model((*linkTokenV1)(nil)).
+				Index("magic_link_tokens_email_idx").
+				Column("email").
+				IfNotExists().
+				Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropIndex().Index("magic_link_tokens_email_idx").IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+}
+
+// Migrate applies all of the magic link token migrations that have not been
+// applied to the database yet.
+func Migrate(ctx context.Context, db *bun.DB) error {
+	return sqlmigrate.Run(ctx, db, Migrations, MigrationsTableName, MigrationLocksTableName)
+}
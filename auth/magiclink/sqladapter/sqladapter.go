@@ -0,0 +1,141 @@
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lukeshay/g/auth/magiclink"
+	"github.com/uptrace/bun"
+)
+
+// LinkToken is the model for the magic_link_tokens table created by
+// [Migrations].
+type LinkToken struct {
+	bun.BaseModel `bun:"table:magic_link_tokens"`
+
+	TokenHash        string    `bun:",pk"`
+	BrowserTokenHash string    `bun:",notnull"`
+	Email            string    `bun:",notnull"`
+	CreatedAt        time.Time `bun:",notnull"`
+	ExpiresAt        time.Time `bun:",notnull"`
+	UsedAt           time.Time `bun:",nullzero"`
+}
+
+// SQLAdapter is an implementation of the magiclink.Adapter interface that
+// stores link tokens in any database supported by bun.
+type SQLAdapter struct {
+	db bun.IDB
+}
+
+type NewOptions struct {
+	// DB is the database or transaction the link tokens are stored in.
+	DB bun.IDB
+}
+
+// New returns a new instance of SQLAdapter.
+func New(options NewOptions) magiclink.Adapter {
+	return &SQLAdapter{
+		db: options.DB,
+	}
+}
+
+func (a *SQLAdapter) InsertToken(ctx context.Context, token magiclink.LinkToken) error {
+	_, err := a.db.NewInsert().Model(&LinkToken{
+		TokenHash:        token.TokenHash,
+		BrowserTokenHash: token.BrowserTokenHash,
+		Email:            token.Email,
+		CreatedAt:        token.CreatedAt,
+		ExpiresAt:        token.ExpiresAt,
+		UsedAt:           token.UsedAt,
+	}).Exec(ctx)
+
+	return err
+}
+
+// UseToken sets used_at only where it is still null, so when two calls race
+// only one of them updates the row and sees the token as unused.
+func (a *SQLAdapter) UseToken(ctx context.Context, tokenHash string, usedAt time.Time) (magiclink.LinkToken, error) {
+	var token magiclink.LinkToken
+
+	err := a.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		model := &LinkToken{}
+
+		err := tx.NewSelect().Model(model).Where("token_hash = ?", tokenHash).Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return magiclink.ErrTokenNotFound
+		} else if err != nil {
+			return err
+		}
+
+		if model.UsedAt.IsZero() {
+			result, err := tx.NewUpdate().
+				Model((*LinkToken)(nil)).
+				Set("used_at = ?", usedAt).
+				Where("token_hash = ?", tokenHash).
+				Where("used_at IS NULL").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			updated, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if updated == 0 {
+				model.UsedAt = usedAt
+			}
+		}
+
+		token = toLinkToken(model)
+
+		return nil
+	})
+	if err != nil {
+		return magiclink.LinkToken{}, err
+	}
+
+	return token, nil
+}
+
+// GetLatestToken returns the most recently created token for the email
+// address. The email index created by Migrations keeps this cheap.
+func (a *SQLAdapter) GetLatestToken(ctx context.Context, email string) (magiclink.LinkToken, error) {
+	model := &LinkToken{}
+
+	err := a.db.NewSelect().
+		Model(model).
+		Where("email = ?", email).
+		Order("created_at DESC").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return magiclink.LinkToken{}, magiclink.ErrTokenNotFound
+	} else if err != nil {
+		return magiclink.LinkToken{}, err
+	}
+
+	return toLinkToken(model), nil
+}
+
+// DeleteExpiredTokens deletes all expired link tokens. The expires_at index
+// created by Migrations keeps this cheap.
+func (a *SQLAdapter) DeleteExpiredTokens(ctx context.Context) error {
+	_, err := a.db.NewDelete().Model((*LinkToken)(nil)).Where("expires_at < ?", time.Now()).Exec(ctx)
+
+	return err
+}
+
+func toLinkToken(model *LinkToken) magiclink.LinkToken {
+	return magiclink.LinkToken{
+		TokenHash:        model.TokenHash,
+		BrowserTokenHash: model.BrowserTokenHash,
+		Email:            model.Email,
+		CreatedAt:        model.CreatedAt,
+		ExpiresAt:        model.ExpiresAt,
+		UsedAt:           model.UsedAt,
+	}
+}
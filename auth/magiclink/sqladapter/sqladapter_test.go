@@ -0,0 +1,173 @@
+package sqladapter_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth/magiclink"
+	"github.com/lukeshay/g/auth/magiclink/sqladapter"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+)
+
+func newTestAdapter(t *testing.T) (magiclink.Adapter, *bun.DB) {
+	t.Helper()
+
+	sqldb, err := sql.Open(sqliteshim.ShimName, fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	err = sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("error migrating database: %v", err)
+	}
+
+	return sqladapter.New(sqladapter.NewOptions{DB: db}), db
+}
+
+func newTestToken(tokenHash string, email string, createdAt time.Time) magiclink.LinkToken {
+	return magiclink.LinkToken{
+		TokenHash:        tokenHash,
+		BrowserTokenHash: "browser-" + tokenHash,
+		Email:            email,
+		CreatedAt:        createdAt,
+		ExpiresAt:        createdAt.Add(15 * time.Minute),
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	_, db := newTestAdapter(t)
+
+	err := sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("expected migrations to be idempotent, got %v", err)
+	}
+}
+
+func TestSQLAdapterUseToken(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	err := adapter.InsertToken(ctx, newTestToken("a", "user@example.com", now))
+	if err != nil {
+		t.Fatalf("error inserting token: %v", err)
+	}
+
+	token, err := adapter.UseToken(ctx, "a", now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("error using token: %v", err)
+	}
+
+	if !token.UsedAt.IsZero() || token.BrowserTokenHash != "browser-a" || token.Email != "user@example.com" {
+		t.Errorf("expected the token as it was before it was used, got %+v", token)
+	}
+
+	token, err = adapter.UseToken(ctx, "a", now.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("error using token: %v", err)
+	}
+
+	if !token.UsedAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected the time the token was first used, got %v", token.UsedAt)
+	}
+
+	_, err = adapter.UseToken(ctx, "unknown", now)
+	if !errors.Is(err, magiclink.ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound, got %v", err)
+	}
+}
+
+func TestSQLAdapterUseTokenConcurrently(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+
+	err := adapter.InsertToken(ctx, newTestToken("a", "user@example.com", time.Now()))
+	if err != nil {
+		t.Fatalf("error inserting token: %v", err)
+	}
+
+	unused := atomic.Int32{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			token, err := adapter.UseToken(ctx, "a", time.Now())
+			if err != nil {
+				t.Errorf("error using token: %v", err)
+			} else if token.UsedAt.IsZero() {
+				unused.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if unused.Load() != 1 {
+		t.Fatalf("expected 1 call to see the token as unused, got %d", unused.Load())
+	}
+}
+
+func TestSQLAdapterLatestAndExpiredTokens(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, token := range []magiclink.LinkToken{
+		newTestToken("expired", "user@example.com", now.Add(-time.Hour)),
+		newTestToken("a", "user@example.com", now.Add(-time.Minute)),
+		newTestToken("b", "user@example.com", now),
+		newTestToken("c", "other@example.com", now.Add(time.Minute)),
+	} {
+		err := adapter.InsertToken(ctx, token)
+		if err != nil {
+			t.Fatalf("error inserting token: %v", err)
+		}
+	}
+
+	latest, err := adapter.GetLatestToken(ctx, "user@example.com")
+	if err != nil {
+		t.Fatalf("error getting latest token: %v", err)
+	}
+
+	if latest.TokenHash != "b" {
+		t.Errorf("expected the latest token to be b, got %q", latest.TokenHash)
+	}
+
+	_, err = adapter.GetLatestToken(ctx, "unknown@example.com")
+	if !errors.Is(err, magiclink.ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound, got %v", err)
+	}
+
+	err = adapter.DeleteExpiredTokens(ctx)
+	if err != nil {
+		t.Fatalf("error deleting expired tokens: %v", err)
+	}
+
+	_, err = adapter.UseToken(ctx, "expired", now)
+	if !errors.Is(err, magiclink.ErrTokenNotFound) {
+		t.Fatalf("expected the expired token to be deleted, got %v", err)
+	}
+
+	_, err = adapter.UseToken(ctx, "a", now)
+	if err != nil {
+		t.Fatalf("expected the token to remain, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package netauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/jwt"
+)
+
+// GetClaims verifies the bearer token in the request's Authorization header
+// with the Verifier and returns its claims. The claims are stored in the
+// context under ClaimsContextKey.
+func (a *NetAuth) GetClaims(ctx context.Context, r *http.Request) (context.Context, jwt.Claims, error) {
+	if claims, ok := ctx.Value(ClaimsContextKey).(jwt.Claims); ok {
+		return ctx, claims, nil
+	}
+
+	if a.verifier == nil {
+		return ctx, nil, fmt.Errorf("%w: bearer tokens are not accepted", jwt.ErrInvalidToken)
+	}
+
+	token, ok := bearerToken(r)
+	if !ok {
+		return ctx, nil, fmt.Errorf("%w: missing bearer token", jwt.ErrInvalidToken)
+	}
+
+	claims, err := a.verifier.Verify(ctx, token)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	ctx = context.WithValue(ctx, ClaimsContextKey, claims)
+
+	return ctx, claims, nil
+}
+
+// Authenticate returns middleware that only lets through requests with a
+// valid bearer token or session. Requests with a bearer token are verified
+// with the Verifier and their claims are stored under ClaimsContextKey. All
+// other requests need a session cookie and their session is stored under
+// SessionContextKey. It responds with 401 Unauthorized when the request is not
+// authenticated.
+func (a *NetAuth) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := bearerToken(r); ok && a.verifier != nil {
+			ctx, _, err := a.GetClaims(r.Context(), r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			return
+		}
+
+		ctx, _, err := a.GetSession(r.Context(), r)
+		if errors.Is(err, auth.ErrSessionNotFound) || errors.Is(err, auth.ErrSessionExpired) || errors.Is(err, auth.ErrInvalidCookie) {
+			if a.verifier != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		} else if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
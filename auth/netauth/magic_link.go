@@ -0,0 +1,179 @@
+package netauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/magiclink"
+)
+
+type MagicLinkHandlerOptions struct {
+	Service *magiclink.Service
+	// NewSession returns the session to create for the user with the email
+	// address. This is where users are looked up or signed up.
+	NewSession func(ctx context.Context, email string) (auth.Session, error)
+	// CookieName is the name of the cookie that binds the link to the browser.
+	// Defaults to magiclink.DefaultCookieName.
+	CookieName string
+	// RedirectURL is where the user is sent after they are signed in.
+	// Defaults to "/".
+	RedirectURL string
+	// OnError is called with the errors that are not returned to the client
+	// and when a link is reused, which can mean it was intercepted. Errors are
+	// ignored when it is nil.
+	OnError func(*http.Request, error)
+	// ConfirmPage renders the page the link opens. It must POST the "token"
+	// query parameter as the "token" form value to VerifyMagicLinkHandler.
+	// Defaults to magiclink.WriteConfirmPage.
+	ConfirmPage http.Handler
+}
+
+// SendMagicLinkHandler returns a handler that emails a magic link to the
+// address in the "email" form value and sets a cookie that binds the link to
+// the browser. It responds with 202 Accepted, or 429 Too Many Requests when
+// the browser was sent a link to the address less than the Service's
+// ResendInterval ago.
+func (a *NetAuth) SendMagicLinkHandler(options MagicLinkHandlerOptions) http.Handler {
+	options = withMagicLinkDefaults(options)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		email := r.FormValue("email")
+		if email == "" {
+			http.Error(w, "email is required", http.StatusBadRequest)
+
+			return
+		}
+
+		previousBrowserToken := ""
+		if cookie, err := r.Cookie(options.CookieName); err == nil {
+			previousBrowserToken = cookie.Value
+		}
+
+		browserToken, err := options.Service.SendLink(r.Context(), email, previousBrowserToken)
+		if err != nil {
+			status, message, ok := magiclink.ClientError(err)
+			if !ok && options.OnError != nil {
+				options.OnError(r, err)
+			}
+
+			http.Error(w, message, status)
+
+			return
+		}
+
+		http.SetCookie(w, a.magicLinkCookie(options.CookieName, browserToken, time.Now().Add(options.Service.TTL())))
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// VerifyMagicLinkHandler returns the handler the link points to. A GET renders
+// ConfirmPage, which POSTs the link token back, so that link scanners and
+// prefetching do not use up the link. A POST verifies the link token in the
+// "token" form value against the browser's cookie, creates a session with
+// CreateNewSession, and redirects to RedirectURL. It responds with 400 Bad
+// Request when the link is invalid, expired, already used, or was requested
+// from a different browser.
+func (a *NetAuth) VerifyMagicLinkHandler(options MagicLinkHandlerOptions) http.Handler {
+	options = withMagicLinkDefaults(options)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setTokenPageHeaders(w)
+
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			options.ConfirmPage.ServeHTTP(w, r)
+
+			return
+		} else if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		browserToken := ""
+		if cookie, err := r.Cookie(options.CookieName); err == nil {
+			browserToken = cookie.Value
+		}
+
+		http.SetCookie(w, a.magicLinkCookie(options.CookieName, "", time.Unix(0, 0)))
+
+		linkToken, err := options.Service.VerifyLink(r.Context(), r.PostFormValue("token"), browserToken)
+		if err != nil {
+			// A reused link can mean the link was intercepted, so it is
+			// reported along with the errors that are not the client's.
+			status, message, ok := magiclink.ClientError(err)
+			if (!ok || errors.Is(err, magiclink.ErrTokenReused)) && options.OnError != nil {
+				options.OnError(r, err)
+			}
+
+			http.Error(w, message, status)
+
+			return
+		}
+
+		newSession, err := options.NewSession(r.Context(), linkToken.Email)
+		if err == nil {
+			_, _, err = a.CreateNewSession(r.Context(), w, newSession)
+		}
+		if err != nil {
+			if options.OnError != nil {
+				options.OnError(r, err)
+			}
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		http.Redirect(w, r, options.RedirectURL, http.StatusSeeOther)
+	})
+}
+
+func (a *NetAuth) magicLinkCookie(name string, value string, expiresAt time.Time) *http.Cookie {
+	return &http.Cookie{
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Name:     name,
+		Path:     a.cookieOptions.Path,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   a.cookieOptions.Secure,
+		Value:    value,
+	}
+}
+
+func withMagicLinkDefaults(options MagicLinkHandlerOptions) MagicLinkHandlerOptions {
+	if options.CookieName == "" {
+		options.CookieName = magiclink.DefaultCookieName
+	}
+
+	if options.RedirectURL == "" {
+		options.RedirectURL = "/"
+	}
+
+	if options.ConfirmPage == nil {
+		options.ConfirmPage = http.HandlerFunc(writeMagicLinkConfirmPage)
+	}
+
+	return options
+}
+
+func writeMagicLinkConfirmPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	_ = magiclink.WriteConfirmPage(w, r.URL.Query().Get("token"))
+}
@@ -0,0 +1,96 @@
+package netauth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	adaptors "github.com/lukeshay/g/auth/adapters"
+	"github.com/lukeshay/g/auth/magiclink"
+	"github.com/lukeshay/g/auth/netauth"
+	"github.com/lukeshay/g/auth/verification"
+)
+
+func TestMagicLinkHandlers(t *testing.T) {
+	a := newTestNetAuth(t, netauth.NewOptions{})
+	mailer := verification.NewInMemoryMailer()
+	options := netauth.MagicLinkHandlerOptions{
+		Service: magiclink.New(magiclink.NewOptions{
+			Adapter: magiclink.NewInMemoryAdapter(),
+			Mailer:  mailer,
+			Compose: func(email string, token string) verification.Message {
+				return verification.Message{To: email, Body: token}
+			},
+		}),
+		NewSession: func(ctx context.Context, email string) (auth.Session, error) {
+			return &adaptors.Session{UserID: email, ExpiresAt: time.Now().Add(time.Hour), RefreshUntil: time.Now().Add(time.Hour)}, nil
+		},
+		OnError: func(r *http.Request, err error) {
+			t.Errorf("unexpected error: %v", err)
+		},
+	}
+	send := a.SendMagicLinkHandler(options)
+	verify := a.VerifyMagicLinkHandler(options)
+
+	w := httptest.NewRecorder()
+	send.ServeHTTP(w, newFormRequest("/magic-link", url.Values{"email": {"user@example.com"}}))
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", w.Code)
+	}
+
+	browserCookie := w.Result().Cookies()[0]
+	if browserCookie.Name != magiclink.DefaultCookieName || !browserCookie.HttpOnly {
+		t.Fatalf("unexpected cookie: %+v", browserCookie)
+	}
+
+	r := newFormRequest("/magic-link", url.Values{"email": {"user@example.com"}})
+	r.AddCookie(browserCookie)
+
+	w = httptest.NewRecorder()
+	send.ServeHTTP(w, r)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the same browser to be throttled with 429, got %d", w.Code)
+	}
+
+	token := mailer.Messages()[0].Body
+
+	w = httptest.NewRecorder()
+	verify.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/magic-link/verify?token="+token, nil))
+
+	if w.Code != http.StatusOK || len(mailer.Messages()) != 1 {
+		t.Fatalf("expected the confirm page, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	verify.ServeHTTP(w, newFormRequest("/magic-link/verify", url.Values{"token": {token}}))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without the browser cookie, got %d", w.Code)
+	}
+
+	// The link was used up by the other browser, so a new one is needed.
+	r = newFormRequest("/magic-link", url.Values{"email": {"other@example.com"}})
+	w = httptest.NewRecorder()
+	send.ServeHTTP(w, r)
+
+	r = newFormRequest("/magic-link/verify", url.Values{"token": {mailer.Messages()[1].Body}})
+	r.AddCookie(w.Result().Cookies()[0])
+
+	w = httptest.NewRecorder()
+	verify.ServeHTTP(w, r)
+
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("expected a redirect, got %d", w.Code)
+	}
+
+	session := sessionFromResponse(t, a, w)
+	if session.GetUserID() != "other@example.com" {
+		t.Errorf("unexpected session: %+v", session)
+	}
+}
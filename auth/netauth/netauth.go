@@ -7,11 +7,17 @@ import (
 	"time"
 
 	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/jwt"
 )
 
 type contextKey string
 
-const SessionContextKey contextKey = "session"
+const (
+	SessionContextKey contextKey = "session"
+	// ClaimsContextKey is the context key of the claims of a verified bearer
+	// token.
+	ClaimsContextKey contextKey = "claims"
+)
 
 type Validate func(context.Context, *http.Request, auth.Session) (context.Context, error)
 
@@ -19,31 +25,48 @@ type CookieOptions struct {
 	Name   string
 	Path   string
 	Secure bool
+	// AllowUnboundCookies allows session cookies that were not bound to the
+	// cookie name when they were encrypted, such as cookies created before the
+	// Encrypter supported additional data. Enable it while migrating and use
+	// ReissueCookie to bind existing cookies.
+	AllowUnboundCookies bool
 }
 
 type NetAuth struct {
 	service       *auth.SessionService
 	validate      Validate
 	cookieOptions CookieOptions
+	verifier      *jwt.Verifier
 }
 
 type NewOptions struct {
-	Adapter       auth.SessionAdapter
-	Encrypter     auth.Encrypter
-	Generator     auth.Generator
-	CookieOptions CookieOptions
-	Validate      Validate
+	Adapter   auth.SessionAdapter
+	Encrypter auth.Encrypter
+	Generator auth.Generator
+	// Hasher hashes session IDs before they are stored. Defaults to SHA-256.
+	Hasher auth.Hasher
+	// LegacySessionIDs enables the temporary migration of raw session IDs. See
+	// auth.NewSessionServiceOptions.
+	LegacySessionIDs bool
+	CookieOptions    CookieOptions
+	Validate         Validate
+	// Verifier verifies bearer tokens. Bearer tokens are not accepted when it
+	// is nil.
+	Verifier *jwt.Verifier
 }
 
 func New(options NewOptions) *NetAuth {
 	return &NetAuth{
 		service: auth.NewSessionService(auth.NewSessionServiceOptions{
-			Adapter:   options.Adapter,
-			Encrypter: options.Encrypter,
-			Generator: options.Generator,
+			Adapter:          options.Adapter,
+			Encrypter:        options.Encrypter,
+			Generator:        options.Generator,
+			Hasher:           options.Hasher,
+			LegacySessionIDs: options.LegacySessionIDs,
 		}),
 		validate:      options.Validate,
 		cookieOptions: options.CookieOptions,
+		verifier:      options.Verifier,
 	}
 }
 
@@ -118,6 +141,64 @@ func (e *NetAuth) GetSessionAndRefresh(ctx context.Context, w http.ResponseWrite
 	return ctx, session, nil
 }
 
+// RotateSession moves the session to a new session ID and sets a cookie for
+// it. See auth.SessionService.RotateSession.
+func (e *NetAuth) RotateSession(ctx context.Context, w http.ResponseWriter, session auth.Session) (context.Context, auth.Session, error) {
+	session, err := e.service.RotateSession(ctx, session)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	cookie, err := e.CreateCookie(session)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	http.SetCookie(w, cookie)
+
+	ctx = context.WithValue(ctx, SessionContextKey, session)
+
+	return ctx, session, nil
+}
+
+// RequireAuthenticationLevel gets the session and returns an error wrapping
+// auth.ErrInsufficientAuthenticationLevel when it was authenticated with a
+// lower level than the given level. Use it to keep sessions that have not
+// verified their second factor out of everything but the MFA endpoints.
+func (e *NetAuth) RequireAuthenticationLevel(ctx context.Context, r *http.Request, level auth.AuthenticationLevel) (context.Context, auth.Session, error) {
+	ctx, session, err := e.GetSession(ctx, r)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	err = auth.RequireAuthenticationLevel(session, level)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	return ctx, session, nil
+}
+
+// UpgradeSession raises the authentication level of the session and sets a
+// cookie for its new session ID. See auth.SessionService.UpgradeSession.
+func (e *NetAuth) UpgradeSession(ctx context.Context, w http.ResponseWriter, session auth.Session, level auth.AuthenticationLevel, factor string) (context.Context, auth.Session, error) {
+	session, err := e.service.UpgradeSession(ctx, session, level, factor)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	cookie, err := e.CreateCookie(session)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	http.SetCookie(w, cookie)
+
+	ctx = context.WithValue(ctx, SessionContextKey, session)
+
+	return ctx, session, nil
+}
+
 func (e *NetAuth) InvalidateSession(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, error) {
 	ctx, session, err := e.GetSession(ctx, r)
 	if err != nil {
@@ -136,6 +217,31 @@ func (e *NetAuth) InvalidateSession(ctx context.Context, w http.ResponseWriter,
 	return ctx, nil
 }
 
+// ReissueCookie sets a new session cookie when the request's cookie was
+// encrypted with a key that is no longer the active key or was not bound to the
+// cookie name. This lets the keys of a keyring Encrypter be rotated without
+// signing anyone out.
+func (a *NetAuth) ReissueCookie(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	cookie, err := r.Cookie(a.cookieOptions.Name)
+	if err != nil || !a.cookieNeedsReissue(cookie.Value) {
+		return ctx, nil
+	}
+
+	ctx, session, err := a.GetSession(ctx, r)
+	if err != nil {
+		return ctx, err
+	}
+
+	newCookie, err := a.CreateCookie(session)
+	if err != nil {
+		return ctx, err
+	}
+
+	http.SetCookie(w, newCookie)
+
+	return ctx, nil
+}
+
 func (a *NetAuth) GetSessionFromCookies(ctx context.Context, cookies []*http.Cookie) (auth.Session, error) {
 	for _, cookie := range cookies {
 		if cookie.Name == a.cookieOptions.Name {
@@ -143,22 +249,22 @@ func (a *NetAuth) GetSessionFromCookies(ctx context.Context, cookies []*http.Coo
 		}
 	}
 
-	return nil, fmt.Errorf("session not found")
+	return nil, fmt.Errorf("%w: cookie %s not found", auth.ErrInvalidCookie, a.cookieOptions.Name)
 }
 
 func (a *NetAuth) GetSessionFromCookie(ctx context.Context, cookie *http.Cookie) (auth.Session, error) {
-	decryptedSessionID, err := a.service.DecryptSessionID(cookie.Value)
+	decryptedSessionID, err := a.decryptSessionID(cookie.Value)
 	if err != nil {
-		return nil, fmt.Errorf("error decrypting session id: %s", err.Error())
+		return nil, fmt.Errorf("%w: %w", auth.ErrInvalidCookie, err)
 	}
 
 	return a.service.GetSession(ctx, decryptedSessionID)
 }
 
 func (a *NetAuth) CreateCookie(session auth.Session) (*http.Cookie, error) {
-	encryptedSessionID, err := a.service.EncryptSessionID(session.GetSessionID())
+	encryptedSessionID, err := a.service.EncryptSessionIDWithAAD(session.GetSessionID(), a.cookieOptions.Name)
 	if err != nil {
-		return a.createCookie(time.Now(), ""), fmt.Errorf("error encrypting session id: %s", err.Error())
+		return a.createCookie(time.Now(), ""), fmt.Errorf("error encrypting session id: %w", err)
 	}
 
 	return a.createCookie(session.GetExpiresAt(), encryptedSessionID), nil
@@ -168,6 +274,27 @@ func (a *NetAuth) EmptyCookie() *http.Cookie {
 	return a.createCookie(time.Now(), "")
 }
 
+// decryptSessionID decrypts the session ID bound to the cookie name. Unbound
+// session IDs are only decrypted when AllowUnboundCookies is set.
+func (a *NetAuth) decryptSessionID(value string) (string, error) {
+	sessionID, err := a.service.DecryptSessionIDWithAAD(value, a.cookieOptions.Name)
+	if err != nil && a.cookieOptions.AllowUnboundCookies {
+		return a.service.DecryptSessionID(value)
+	}
+
+	return sessionID, err
+}
+
+func (a *NetAuth) cookieNeedsReissue(value string) bool {
+	if a.service.SessionIDNeedsRotation(value) {
+		return true
+	}
+
+	_, err := a.service.DecryptSessionIDWithAAD(value, a.cookieOptions.Name)
+
+	return err != nil
+}
+
 func (a *NetAuth) createCookie(expiresAt time.Time, value string) *http.Cookie {
 	return &http.Cookie{
 		Expires:  expiresAt,
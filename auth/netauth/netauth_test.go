@@ -0,0 +1,62 @@
+package netauth_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lukeshay/g/auth"
+	adaptors "github.com/lukeshay/g/auth/adapters"
+	"github.com/lukeshay/g/auth/encrypters"
+	"github.com/lukeshay/g/auth/generators"
+	"github.com/lukeshay/g/auth/netauth"
+)
+
+func newTestEncrypter(t *testing.T) auth.Encrypter {
+	t.Helper()
+
+	encrypter, err := encrypters.NewAesGcmEncrypter(encrypters.NewAesGcmEncrypterOptions{Key: bytes.Repeat([]byte{1}, 32)})
+	if err != nil {
+		t.Fatalf("error creating encrypter: %v", err)
+	}
+
+	return encrypter
+}
+
+func newTestNetAuth(t *testing.T, options netauth.NewOptions) *netauth.NetAuth {
+	t.Helper()
+
+	options.Adapter = adaptors.NewInMemoryAdapter()
+	options.Encrypter = newTestEncrypter(t)
+	options.Generator = generators.NewBase32LowerGenerator(20)
+	options.CookieOptions = netauth.CookieOptions{Name: "session", Path: "/"}
+	options.Validate = func(ctx context.Context, r *http.Request, session auth.Session) (context.Context, error) {
+		return ctx, nil
+	}
+
+	return netauth.New(options)
+}
+
+func newFormRequest(target string, values url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return r
+}
+
+// sessionFromResponse returns the session for the session cookie the response
+// set.
+func sessionFromResponse(t *testing.T, a *netauth.NetAuth, w *httptest.ResponseRecorder) auth.Session {
+	t.Helper()
+
+	session, err := a.GetSessionFromCookies(context.Background(), w.Result().Cookies())
+	if err != nil {
+		t.Fatalf("expected a session cookie, got %v", err)
+	}
+
+	return session
+}
@@ -0,0 +1,85 @@
+package netauth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lukeshay/g/auth/oauth"
+)
+
+type OAuthHandlerOptions struct {
+	Client *oauth.Client
+	// NewSession maps the provider's claims to the session to create.
+	NewSession oauth.NewSession
+	// RedirectURL is where the user is sent after they are signed in.
+	// Defaults to "/".
+	RedirectURL string
+	// OnError is called with the errors that are not returned to the client.
+	// Errors are ignored when it is nil.
+	OnError func(*http.Request, error)
+}
+
+// OAuthLoginHandler returns a handler that starts the authorization code flow
+// and redirects the user to the provider.
+func (a *NetAuth) OAuthLoginHandler(options OAuthHandlerOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authURL, err := options.Client.Begin(w)
+		if err != nil {
+			if options.OnError != nil {
+				options.OnError(r, err)
+			}
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		http.Redirect(w, r, authURL, http.StatusFound)
+	})
+}
+
+// OAuthCallbackHandler returns a handler that completes the authorization
+// code flow, creates a session with CreateNewSession, and redirects to
+// RedirectURL. It responds with 400 Bad Request when the state does not match
+// or the provider returned an error.
+func (a *NetAuth) OAuthCallbackHandler(options OAuthHandlerOptions) http.Handler {
+	redirectURL := options.RedirectURL
+	if redirectURL == "" {
+		redirectURL = "/"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setTokenPageHeaders(w)
+
+		result, err := options.Client.Complete(r.Context(), w, r)
+		if errors.Is(err, oauth.ErrInvalidState) || errors.Is(err, oauth.ErrProvider) {
+			http.Error(w, "sign in failed, please try again", http.StatusBadRequest)
+
+			return
+		} else if err != nil {
+			if options.OnError != nil {
+				options.OnError(r, err)
+			}
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		newSession, err := options.NewSession(r.Context(), result)
+		if err == nil {
+			_, _, err = a.CreateNewSession(r.Context(), w, newSession)
+		}
+		if err != nil {
+			if options.OnError != nil {
+				options.OnError(r, err)
+			}
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+	})
+}
@@ -0,0 +1,123 @@
+package netauth_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	adaptors "github.com/lukeshay/g/auth/adapters"
+	"github.com/lukeshay/g/auth/netauth"
+	"github.com/lukeshay/g/auth/oauth"
+)
+
+// newOAuthProvider returns a stub OAuth 2.0 token endpoint that checks the
+// authorization code and the PKCE verifier against the challenge from the
+// last authorization request.
+func newOAuthProvider(t *testing.T, challenge *string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "code" || base64.RawURLEncoding.EncodeToString(verifier[:]) != *challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestOAuthHandlers(t *testing.T) {
+	a := newTestNetAuth(t, netauth.NewOptions{})
+	challenge := ""
+	server := newOAuthProvider(t, &challenge)
+
+	client, err := oauth.New(oauth.NewOptions{
+		ClientID:    "client",
+		AuthURL:     server.URL + "/authorize",
+		TokenURL:    server.URL + "/token",
+		RedirectURL: "https://app.example.com/callback",
+		Encrypter:   newTestEncrypter(t),
+		HTTPClient:  server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+
+	options := netauth.OAuthHandlerOptions{
+		Client: client,
+		NewSession: func(ctx context.Context, result *oauth.Result) (auth.Session, error) {
+			return &adaptors.Session{UserID: result.Token.AccessToken, ExpiresAt: time.Now().Add(time.Hour), RefreshUntil: time.Now().Add(time.Hour)}, nil
+		},
+		RedirectURL: "/home",
+		OnError: func(r *http.Request, err error) {
+			t.Errorf("unexpected error: %v", err)
+		},
+	}
+
+	w := httptest.NewRecorder()
+	a.OAuthLoginHandler(options).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected a redirect to the provider, got %d", w.Code)
+	}
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil || location.Host != server.Listener.Addr().String() {
+		t.Fatalf("unexpected redirect: %s", w.Header().Get("Location"))
+	}
+
+	challenge = location.Query().Get("code_challenge")
+	stateCookies := w.Result().Cookies()
+	callback := a.OAuthCallbackHandler(options)
+
+	r := httptest.NewRequest(http.MethodGet, "/callback?code=code&state=forged", nil)
+	for _, cookie := range stateCookies {
+		r.AddCookie(cookie)
+	}
+
+	w = httptest.NewRecorder()
+	callback.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a forged state, got %d", w.Code)
+	}
+
+	_, err = a.GetSessionFromCookies(context.Background(), w.Result().Cookies())
+	if err == nil {
+		t.Fatalf("expected no session for a forged state")
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/callback?code=code&state="+url.QueryEscape(location.Query().Get("state")), nil)
+	for _, cookie := range stateCookies {
+		r.AddCookie(cookie)
+	}
+
+	w = httptest.NewRecorder()
+	callback.ServeHTTP(w, r)
+
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/home" {
+		t.Fatalf("expected a redirect to /home, got %d: %s", w.Code, w.Body.String())
+	}
+
+	session := sessionFromResponse(t, a, w)
+	if session.GetUserID() != "access" {
+		t.Errorf("unexpected session: %+v", session)
+	}
+}
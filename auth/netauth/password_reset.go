@@ -0,0 +1,164 @@
+package netauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/lukeshay/g/auth/passwordpolicy"
+	"github.com/lukeshay/g/auth/passwordreset"
+)
+
+// DefaultMaxPendingResetRequests is the number of password reset requests
+// that can be sent in the background at the same time when
+// MaxPendingRequests is zero.
+const DefaultMaxPendingResetRequests = 32
+
+type PasswordResetHandlerOptions struct {
+	Service *passwordreset.Service
+	// OnError is called with the errors that are not returned to the client,
+	// such as errors sending the reset email. Errors are ignored when it is
+	// nil. Errors from requests that are handled in the background are called
+	// with a copy of the request.
+	OnError func(*http.Request, error)
+	// MaxPendingRequests is the number of reset requests that can be sent in
+	// the background at the same time. Requests are rejected with 503 Service
+	// Unavailable while it is reached. Defaults to
+	// DefaultMaxPendingResetRequests.
+	MaxPendingRequests int
+}
+
+// RequestPasswordResetHandler returns a handler that emails a password reset
+// token to the address in the "email" form value. The email is sent in the
+// background and the handler responds with 202 Accepted, so neither the
+// response nor how long it takes reveals whether the address has an account.
+// Requests for the same address are throttled by the Service's
+// ResendInterval and at most MaxPendingRequests are sent at the same time.
+func (a *NetAuth) RequestPasswordResetHandler(options PasswordResetHandlerOptions) http.Handler {
+	maxPendingRequests := options.MaxPendingRequests
+	if maxPendingRequests <= 0 {
+		maxPendingRequests = DefaultMaxPendingResetRequests
+	}
+
+	pending := make(chan struct{}, maxPendingRequests)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setTokenPageHeaders(w)
+
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		email := r.FormValue("email")
+		if email == "" {
+			http.Error(w, "email is required", http.StatusBadRequest)
+
+			return
+		}
+
+		select {
+		case pending <- struct{}{}:
+		default:
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+			return
+		}
+
+		// The request must not be used after the handler returns, so the
+		// background work gets a copy with a context that is not cancelled.
+		backgroundRequest := r.Clone(context.WithoutCancel(r.Context()))
+
+		go func() {
+			defer func() { <-pending }()
+
+			err := options.Service.RequestReset(backgroundRequest.Context(), email)
+			if err != nil && !errors.Is(err, passwordreset.ErrResendThrottled) && options.OnError != nil {
+				options.OnError(backgroundRequest, err)
+			}
+		}()
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// CompletePasswordResetHandler returns a handler that sets the password in the
+// "password" form value with the token in the "token" form value. The user is
+// signed out of every session and a cookie is set for a new session. It
+// responds with 204 No Content on success, 400 Bad Request when the token is
+// invalid or expired, and 422 Unprocessable Entity when the password is
+// rejected by the PasswordPolicy.
+func (a *NetAuth) CompletePasswordResetHandler(options PasswordResetHandlerOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setTokenPageHeaders(w)
+
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		session, err := options.Service.CompleteReset(r.Context(), r.FormValue("token"), r.FormValue("password"))
+		if errors.Is(err, passwordreset.ErrTokenNotFound) || errors.Is(err, passwordreset.ErrTokenExpired) {
+			http.Error(w, "password reset token is invalid or expired", http.StatusBadRequest)
+
+			return
+		} else if isPasswordPolicyError(err) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+
+			return
+		} else if err != nil {
+			if options.OnError != nil {
+				options.OnError(r, err)
+			}
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		cookie, err := a.CreateCookie(session)
+		if err != nil {
+			if options.OnError != nil {
+				options.OnError(r, err)
+			}
+
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+			return
+		}
+
+		http.SetCookie(w, cookie)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// PasswordResetPage wraps the handler that renders the password reset page.
+// The page's URL contains the token, so the page must not send it to other
+// sites in the Referer header or be stored in caches.
+func PasswordResetPage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setTokenPageHeaders(w)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setTokenPageHeaders keeps tokens in the URL from leaking to other sites in
+// the Referer header and from being stored in caches.
+func setTokenPageHeaders(w http.ResponseWriter) {
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+func isPasswordPolicyError(err error) bool {
+	return errors.Is(err, passwordpolicy.ErrPasswordTooShort) ||
+		errors.Is(err, passwordpolicy.ErrPasswordTooLong) ||
+		errors.Is(err, passwordpolicy.ErrPasswordMissingCharacters) ||
+		errors.Is(err, passwordpolicy.ErrPasswordBreached)
+}
@@ -0,0 +1,171 @@
+package netauth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	adaptors "github.com/lukeshay/g/auth/adapters"
+	"github.com/lukeshay/g/auth/netauth"
+	"github.com/lukeshay/g/auth/passwordpolicy"
+	"github.com/lukeshay/g/auth/passwordreset"
+	"github.com/lukeshay/g/auth/passwords"
+	"github.com/lukeshay/g/auth/verification"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestPasswordResetOptions(t *testing.T, a *netauth.NetAuth, mailer verification.Mailer) netauth.PasswordResetHandlerOptions {
+	t.Helper()
+
+	service := passwordreset.New(passwordreset.NewOptions{
+		Adapter:        passwordreset.NewInMemoryAdapter(),
+		SessionService: a.Service(),
+		PasswordHasher: passwords.NewBcryptHasher(passwords.NewBcryptHasherOptions{Cost: bcrypt.MinCost}),
+		PasswordPolicy: passwordpolicy.New(passwordpolicy.NewOptions{}),
+		Mailer:         mailer,
+		LookupUser: func(ctx context.Context, email string) (string, error) {
+			if email != "user@example.com" {
+				return "", passwordreset.ErrUserNotFound
+			}
+
+			return "user", nil
+		},
+		UpdatePasswordHash: func(ctx context.Context, userID string, passwordHash string) error {
+			return nil
+		},
+		NewSession: func(userID string) auth.Session {
+			return &adaptors.Session{UserID: userID, ExpiresAt: time.Now().Add(time.Hour), RefreshUntil: time.Now().Add(time.Hour)}
+		},
+		Compose: func(email string, token string) verification.Message {
+			return verification.Message{To: email, Body: token}
+		},
+	})
+
+	return netauth.PasswordResetHandlerOptions{
+		Service: service,
+		OnError: func(r *http.Request, err error) {
+			t.Errorf("unexpected error: %v", err)
+		},
+	}
+}
+
+// waitForMessages waits for the mailer to have count messages, because reset
+// emails are sent in the background.
+func waitForMessages(t *testing.T, mailer *verification.InMemoryMailer, count int) []verification.Message {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+
+	for len(mailer.Messages()) < count && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != count {
+		t.Fatalf("expected %d messages, got %d", count, len(messages))
+	}
+
+	return messages
+}
+
+func TestRequestPasswordResetHandler(t *testing.T) {
+	a := newTestNetAuth(t, netauth.NewOptions{})
+	mailer := verification.NewInMemoryMailer()
+	handler := a.RequestPasswordResetHandler(newTestPasswordResetOptions(t, a, mailer))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reset", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newFormRequest("/reset", url.Values{}))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without an email, got %d", w.Code)
+	}
+
+	// Unknown addresses get the same response so they do not reveal which
+	// addresses have accounts.
+	for _, email := range []string{"unknown@example.com", "user@example.com"} {
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, newFormRequest("/reset", url.Values{"email": {email}}))
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("expected 202 for %s, got %d", email, w.Code)
+		}
+
+		if w.Header().Get("Referrer-Policy") != "no-referrer" || w.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("unexpected headers: %v", w.Header())
+		}
+	}
+
+	messages := waitForMessages(t, mailer, 1)
+	if messages[0].To != "user@example.com" {
+		t.Errorf("unexpected message: %+v", messages[0])
+	}
+}
+
+func TestCompletePasswordResetHandler(t *testing.T) {
+	a := newTestNetAuth(t, netauth.NewOptions{})
+	mailer := verification.NewInMemoryMailer()
+	options := newTestPasswordResetOptions(t, a, mailer)
+	handler := a.CompletePasswordResetHandler(options)
+
+	oldSession, err := a.Service().CreateSession(context.Background(), &adaptors.Session{UserID: "user", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+
+	err = options.Service.RequestReset(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("error requesting reset: %v", err)
+	}
+
+	token := mailer.Messages()[0].Body
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		code     int
+	}{
+		{name: "invalid token", token: "unknown", password: "correct horse battery staple", code: http.StatusBadRequest},
+		{name: "rejected password", token: token, password: "short", code: http.StatusUnprocessableEntity},
+		{name: "valid", token: token, password: "correct horse battery staple", code: http.StatusNoContent},
+		{name: "used token", token: token, password: "correct horse battery staple", code: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newFormRequest("/reset/complete", url.Values{"token": {test.token}, "password": {test.password}}))
+
+		if w.Code != test.code {
+			t.Fatalf("%s: expected %d, got %d: %s", test.name, test.code, w.Code, w.Body.String())
+		}
+
+		if test.code != http.StatusNoContent {
+			if len(w.Result().Cookies()) != 0 {
+				t.Fatalf("%s: expected no cookie, got %v", test.name, w.Result().Cookies())
+			}
+
+			continue
+		}
+
+		session := sessionFromResponse(t, a, w)
+		if session.GetUserID() != "user" {
+			t.Errorf("unexpected session: %+v", session)
+		}
+	}
+
+	_, err = a.Service().GetSession(context.Background(), oldSession.GetSessionID())
+	if err == nil {
+		t.Fatalf("expected the old session to be deleted")
+	}
+}
@@ -0,0 +1,36 @@
+package oauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/lukeshay/g/auth/jwt"
+)
+
+// Claims are the claims of an ID token.
+type Claims = jwt.Claims
+
+// VerifyIDToken verifies the signature of the ID token against the provider's
+// JWKS and checks its issuer, audience, expiry, and nonce as required by
+// OpenID Connect. Tokens must be signed with RS256, ES256, or EdDSA.
+func (c *Client) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (Claims, error) {
+	if c.verifier == nil {
+		return nil, fmt.Errorf("%w: OpenID Connect is not enabled", ErrInvalidIDToken)
+	}
+
+	claims, err := c.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	if azp := claims.String("azp"); (len(claims.Audience()) > 1 || azp != "") && azp != c.clientID {
+		return nil, fmt.Errorf("%w: token was not issued to client %q", ErrInvalidIDToken, c.clientID)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.String("nonce")), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce does not match", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
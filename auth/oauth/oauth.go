@@ -0,0 +1,488 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/generators"
+	"github.com/lukeshay/g/auth/jwt"
+)
+
+var (
+	// ErrInvalidState is returned when the callback request does not match
+	// the flow started by Begin, such as when the state cookie is missing or
+	// expired or the state parameter does not match it.
+	ErrInvalidState = errors.New("invalid oauth state")
+	// ErrProvider is returned when the provider redirects back with an error,
+	// such as when the user denies access.
+	ErrProvider = errors.New("oauth provider returned an error")
+	// ErrTokenExchange is returned when the authorization code cannot be
+	// exchanged for a token.
+	ErrTokenExchange = errors.New("error exchanging authorization code")
+	// ErrInvalidIDToken is returned when the ID token is missing or cannot be
+	// verified.
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+// NewSession returns the session to create for the user that signed in. This
+// is where the provider's claims are mapped to your users.
+type NewSession func(ctx context.Context, result *Result) (auth.Session, error)
+
+// Token is the response of the provider's token endpoint.
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	IDToken      string
+	Scope        string
+	// Expiry is when the access token expires. It is zero when the provider
+	// did not say.
+	Expiry time.Time
+}
+
+// Result is the result of a completed authorization code flow.
+type Result struct {
+	Token *Token
+	// Claims are the claims of the verified ID token. They are empty when
+	// OpenID Connect is not enabled.
+	Claims Claims
+}
+
+// Client implements the OAuth 2.0 authorization code flow with PKCE and,
+// when an Issuer and JWKSURL are configured, OpenID Connect. The state, nonce,
+// and PKCE code verifier of a flow are kept in a cookie encrypted with the
+// Encrypter, so no server side storage is needed.
+type Client struct {
+	clientID     string
+	clientSecret string
+	authURL      string
+	tokenURL     string
+	redirectURL  string
+	scopes       []string
+	issuer       string
+	verifier     *jwt.Verifier
+	encrypter    auth.Encrypter
+	generator    auth.Generator
+	httpClient   *http.Client
+	cookieName   string
+	cookiePath   string
+	cookieSecure bool
+	ttl          time.Duration
+	clockSkew    time.Duration
+	now          func() time.Time
+}
+
+type NewOptions struct {
+	ClientID     string
+	ClientSecret string
+	// AuthURL is the provider's authorization endpoint.
+	AuthURL string
+	// TokenURL is the provider's token endpoint.
+	TokenURL string
+	// RedirectURL is the URL of your callback handler registered with the
+	// provider.
+	RedirectURL string
+	Scopes      []string
+	// Issuer and JWKSURL enable OpenID Connect. The "openid" scope and a nonce
+	// are sent and the ID token is verified against the keys at JWKSURL. The
+	// ID token must have Issuer as its "iss" claim and ClientID in its "aud"
+	// claim, so both are required when JWKSURL or KeySet is set.
+	Issuer  string
+	JWKSURL string
+	// KeySet replaces JWKSURL, such as to read the provider's keys from a
+	// file.
+	KeySet jwt.KeySet
+	// Encrypter encrypts the state cookie. It is required.
+	Encrypter auth.Encrypter
+	// Generator generates the state, nonce, and PKCE code verifier. Defaults
+	// to 32 random bytes encoded as URL safe base64.
+	Generator auth.Generator
+	// HTTPClient is used to call the provider. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// CookieName is the name of the state cookie. Defaults to "oauth_state".
+	CookieName string
+	// CookiePath is the path of the state cookie. Defaults to "/".
+	CookiePath   string
+	CookieSecure bool
+	// TTL is how long the user has to complete the flow. Defaults to 10
+	// minutes.
+	TTL time.Duration
+	// ClockSkew is how much the clocks of the provider and this server may
+	// differ when the ID token is verified. Defaults to 1 minute.
+	ClockSkew time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// New returns a new instance of Client. It returns an error when there is no
+// Encrypter or when OpenID Connect is enabled without an Issuer or ClientID to
+// check the ID token against.
+func New(options NewOptions) (*Client, error) {
+	if options.Encrypter == nil {
+		return nil, errors.New("encrypter is required to protect the state cookie")
+	}
+
+	c := &Client{
+		clientID:     options.ClientID,
+		clientSecret: options.ClientSecret,
+		authURL:      options.AuthURL,
+		tokenURL:     options.TokenURL,
+		redirectURL:  options.RedirectURL,
+		scopes:       options.Scopes,
+		issuer:       options.Issuer,
+		encrypter:    options.Encrypter,
+		generator:    options.Generator,
+		httpClient:   options.HTTPClient,
+		cookieName:   options.CookieName,
+		cookiePath:   options.CookiePath,
+		cookieSecure: options.CookieSecure,
+		ttl:          options.TTL,
+		clockSkew:    options.ClockSkew,
+		now:          options.Now,
+	}
+
+	if c.generator == nil {
+		c.generator = generators.NewBase64URLGenerator(32)
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+
+	if c.cookieName == "" {
+		c.cookieName = "oauth_state"
+	}
+
+	if c.cookiePath == "" {
+		c.cookiePath = "/"
+	}
+
+	if c.ttl == 0 {
+		c.ttl = 10 * time.Minute
+	}
+
+	if c.clockSkew == 0 {
+		c.clockSkew = time.Minute
+	}
+
+	if c.now == nil {
+		c.now = time.Now
+	}
+
+	keySet := options.KeySet
+	if keySet == nil && options.JWKSURL != "" {
+		keySet = jwt.NewRemoteKeySet(jwt.NewRemoteKeySetOptions{
+			URL:        options.JWKSURL,
+			HTTPClient: c.httpClient,
+			Now:        c.now,
+		})
+	}
+
+	if keySet != nil {
+		verifier, err := jwt.NewVerifier(jwt.NewVerifierOptions{
+			KeySet:    keySet,
+			Issuer:    c.issuer,
+			Audience:  c.clientID,
+			ClockSkew: c.clockSkew,
+			Now:       c.now,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error creating id token verifier: %w", err)
+		}
+
+		c.verifier = verifier
+
+		if !slices.Contains(c.scopes, "openid") {
+			c.scopes = append([]string{"openid"}, c.scopes...)
+		}
+	}
+
+	return c, nil
+}
+
+// flowState is stored in the state cookie between Begin and Complete.
+type flowState struct {
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce,omitempty"`
+	CodeVerifier string    `json:"code_verifier"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Begin starts the authorization code flow. It sets the state cookie and
+// returns the provider URL the user should be redirected to.
+func (c *Client) Begin(w http.ResponseWriter) (string, error) {
+	flow := flowState{
+		ExpiresAt: c.now().Add(c.ttl),
+	}
+
+	var err error
+
+	flow.State, err = c.generator.Generate()
+	if err != nil {
+		return "", fmt.Errorf("error generating state: %w", err)
+	}
+
+	flow.CodeVerifier, err = c.generator.Generate()
+	if err != nil {
+		return "", fmt.Errorf("error generating code verifier: %w", err)
+	}
+
+	if c.verifier != nil {
+		flow.Nonce, err = c.generator.Generate()
+		if err != nil {
+			return "", fmt.Errorf("error generating nonce: %w", err)
+		}
+	}
+
+	value, err := c.encryptFlow(flow)
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, c.cookie(value, flow.ExpiresAt))
+
+	return c.authCodeURL(flow)
+}
+
+// Complete finishes the authorization code flow in the callback request. It
+// checks the state against the state cookie, exchanges the code for a token,
+// and verifies the ID token when OpenID Connect is enabled. The state cookie is
+// always cleared.
+func (c *Client) Complete(ctx context.Context, w http.ResponseWriter, r *http.Request) (*Result, error) {
+	http.SetCookie(w, c.cookie("", time.Unix(0, 0)))
+
+	cookie, err := r.Cookie(c.cookieName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cookie %s not found", ErrInvalidState, c.cookieName)
+	}
+
+	flow, err := c.decryptFlow(cookie.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidState, err)
+	}
+
+	if flow.ExpiresAt.Before(c.now()) {
+		return nil, fmt.Errorf("%w: flow expired at %s", ErrInvalidState, flow.ExpiresAt.Format(time.RFC3339))
+	}
+
+	query := r.URL.Query()
+
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(flow.State)) != 1 {
+		return nil, fmt.Errorf("%w: state does not match", ErrInvalidState)
+	}
+
+	if c.issuer != "" && query.Has("iss") && query.Get("iss") != c.issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %s", ErrInvalidState, query.Get("iss"))
+	}
+
+	if code := query.Get("error"); code != "" {
+		return nil, fmt.Errorf("%w: %s: %s", ErrProvider, code, query.Get("error_description"))
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return nil, fmt.Errorf("%w: missing code", ErrProvider)
+	}
+
+	token, err := c.Exchange(ctx, code, flow.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Token:  token,
+		Claims: Claims{},
+	}
+
+	if c.verifier != nil {
+		if token.IDToken == "" {
+			return nil, fmt.Errorf("%w: token response does not contain an id token", ErrInvalidIDToken)
+		}
+
+		result.Claims, err = c.VerifyIDToken(ctx, token.IDToken, flow.Nonce)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// tokenResponse is the JSON body of a token endpoint response. Error
+// responses use the error fields.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	IDToken          string `json:"id_token"`
+	Scope            string `json:"scope"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange exchanges the authorization code for a token. The client
+// authenticates with HTTP basic authentication when it has a secret.
+func (c *Client) Exchange(ctx context.Context, code string, codeVerifier string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.redirectURL},
+		"code_verifier": {codeVerifier},
+		"client_id":     {c.clientID},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenExchange, err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if c.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenExchange, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenExchange, err)
+	}
+
+	response := tokenResponse{}
+	jsonErr := json.Unmarshal(body, &response)
+
+	if res.StatusCode != http.StatusOK {
+		if jsonErr == nil && response.Error != "" {
+			return nil, fmt.Errorf("%w: %s: %s", ErrTokenExchange, response.Error, response.ErrorDescription)
+		}
+
+		return nil, fmt.Errorf("%w: unexpected status %d", ErrTokenExchange, res.StatusCode)
+	}
+
+	if jsonErr != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenExchange, jsonErr)
+	}
+
+	if response.AccessToken == "" {
+		return nil, fmt.Errorf("%w: response does not contain an access token", ErrTokenExchange)
+	}
+
+	token := &Token{
+		AccessToken:  response.AccessToken,
+		TokenType:    response.TokenType,
+		RefreshToken: response.RefreshToken,
+		IDToken:      response.IDToken,
+		Scope:        response.Scope,
+	}
+
+	if response.ExpiresIn > 0 {
+		token.Expiry = c.now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
+
+func (c *Client) authCodeURL(flow flowState) (string, error) {
+	authURL, err := url.Parse(c.authURL)
+	if err != nil {
+		return "", fmt.Errorf("error parsing auth url: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(flow.CodeVerifier))
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", c.clientID)
+	query.Set("redirect_uri", c.redirectURL)
+	query.Set("state", flow.State)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	if len(c.scopes) > 0 {
+		query.Set("scope", strings.Join(c.scopes, " "))
+	}
+
+	if flow.Nonce != "" {
+		query.Set("nonce", flow.Nonce)
+	}
+
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// encryptFlow encrypts the flow bound to the cookie name when the Encrypter
+// supports additional data.
+func (c *Client) encryptFlow(flow flowState) (string, error) {
+	value, err := json.Marshal(flow)
+	if err != nil {
+		return "", fmt.Errorf("error encoding state: %w", err)
+	}
+
+	var encrypted string
+	if encrypter, ok := c.encrypter.(auth.AADEncrypter); ok {
+		encrypted, err = encrypter.EncryptWithAAD(string(value), c.cookieName)
+	} else {
+		encrypted, err = c.encrypter.Encrypt(string(value))
+	}
+	if err != nil {
+		return "", fmt.Errorf("error encrypting state: %w", err)
+	}
+
+	return encrypted, nil
+}
+
+func (c *Client) decryptFlow(value string) (flowState, error) {
+	var decrypted string
+	var err error
+
+	if encrypter, ok := c.encrypter.(auth.AADEncrypter); ok {
+		decrypted, err = encrypter.DecryptWithAAD(value, c.cookieName)
+	} else {
+		decrypted, err = c.encrypter.Decrypt(value)
+	}
+	if err != nil {
+		return flowState{}, err
+	}
+
+	flow := flowState{}
+
+	err = json.Unmarshal([]byte(decrypted), &flow)
+	if err != nil {
+		return flowState{}, fmt.Errorf("error decoding state: %w", err)
+	}
+
+	return flow, nil
+}
+
+func (c *Client) cookie(value string, expiresAt time.Time) *http.Cookie {
+	return &http.Cookie{
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Name:     c.cookieName,
+		Path:     c.cookiePath,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   c.cookieSecure,
+		Value:    value,
+	}
+}
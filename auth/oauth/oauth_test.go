@@ -0,0 +1,345 @@
+package oauth_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/encrypters"
+	"github.com/lukeshay/g/auth/oauth"
+)
+
+// provider is a stub OAuth 2.0 and OpenID Connect provider. It checks the
+// client credentials and the PKCE verifier and returns ID tokens signed with
+// the configured algorithm and key.
+type provider struct {
+	server    *httptest.Server
+	rsaKey    *rsa.PrivateKey
+	ecKey     *ecdsa.PrivateKey
+	edKey     ed25519.PrivateKey
+	alg       string
+	kid       string
+	challenge string
+	nonce     string
+	// claims override the default ID token claims. A nil value removes the
+	// claim.
+	claims map[string]any
+}
+
+func newProvider(t *testing.T) *provider {
+	t.Helper()
+
+	p := &provider{alg: "RS256", kid: "rsa"}
+
+	var err error
+
+	p.rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating rsa key: %v", err)
+	}
+
+	p.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating ec key: %v", err)
+	}
+
+	_, p.edKey, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating ed25519 key: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+func (p *provider) jwks(w http.ResponseWriter, r *http.Request) {
+	b64 := base64.RawURLEncoding
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]any{
+			{
+				"kty": "RSA",
+				"kid": "rsa",
+				"n":   b64.EncodeToString(p.rsaKey.N.Bytes()),
+				"e":   b64.EncodeToString(big.NewInt(int64(p.rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec",
+				"crv": "P-256",
+				"x":   b64.EncodeToString(p.ecKey.X.FillBytes(make([]byte, 32))),
+				"y":   b64.EncodeToString(p.ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+			{
+				"kty": "OKP",
+				"kid": "ed",
+				"crv": "Ed25519",
+				"x":   b64.EncodeToString(p.edKey.Public().(ed25519.PublicKey)),
+			},
+		},
+	})
+}
+
+func (p *provider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != "client" || clientSecret != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+
+		return
+	}
+
+	challenge := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if r.FormValue("code") != "code" || base64.RawURLEncoding.EncodeToString(challenge[:]) != p.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+
+		return
+	}
+
+	claims := map[string]any{
+		"iss":            p.server.URL,
+		"aud":            "client",
+		"sub":            "user",
+		"email":          "user@example.com",
+		"email_verified": true,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          p.nonce,
+	}
+
+	for name, value := range p.claims {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     p.sign(claims),
+	})
+}
+
+func (p *provider) sign(claims map[string]any) string {
+	b64 := base64.RawURLEncoding
+
+	header, _ := json.Marshal(map[string]string{"alg": p.alg, "kid": p.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+
+	switch p.alg {
+	case "RS256":
+		signature, _ = p.rsaKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case "ES256":
+		r, s, _ := ecdsa.Sign(rand.Reader, p.ecKey, digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "EdDSA":
+		signature = ed25519.Sign(p.edKey, []byte(signingInput))
+	}
+
+	return signingInput + "." + b64.EncodeToString(signature)
+}
+
+func newEncrypter(t *testing.T) auth.Encrypter {
+	t.Helper()
+
+	encrypter, err := encrypters.NewAesGcmEncrypter(encrypters.NewAesGcmEncrypterOptions{Key: bytes.Repeat([]byte{1}, 32)})
+	if err != nil {
+		t.Fatalf("error creating encrypter: %v", err)
+	}
+
+	return encrypter
+}
+
+func newClient(t *testing.T, p *provider) *oauth.Client {
+	t.Helper()
+
+	client, err := oauth.New(oauth.NewOptions{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		AuthURL:      p.server.URL + "/authorize",
+		TokenURL:     p.server.URL + "/token",
+		RedirectURL:  "https://app.example.com/callback",
+		Scopes:       []string{"email"},
+		Issuer:       p.server.URL,
+		JWKSURL:      p.server.URL + "/jwks",
+		Encrypter:    newEncrypter(t),
+		HTTPClient:   p.server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+
+	return client
+}
+
+// runFlow begins the flow, has the provider redirect back with the given
+// state, and completes the flow. An empty state uses the state from Begin.
+func runFlow(t *testing.T, p *provider, client *oauth.Client, state string) (*oauth.Result, error) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+
+	authURL, err := client.Begin(w)
+	if err != nil {
+		t.Fatalf("error beginning flow: %v", err)
+	}
+
+	location, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("error parsing auth url: %v", err)
+	}
+
+	query := location.Query()
+	if query.Get("scope") != "openid email" || query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "client" {
+		t.Fatalf("unexpected auth url: %s", authURL)
+	}
+
+	p.challenge = query.Get("code_challenge")
+	p.nonce = query.Get("nonce")
+
+	if state == "" {
+		state = query.Get("state")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/callback?code=code&state="+url.QueryEscape(state), nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+
+	return client.Complete(context.Background(), httptest.NewRecorder(), r)
+}
+
+func TestComplete(t *testing.T) {
+	p := newProvider(t)
+	client := newClient(t, p)
+
+	for _, key := range []struct{ alg, kid string }{{"RS256", "rsa"}, {"ES256", "ec"}, {"EdDSA", "ed"}} {
+		t.Run(key.alg, func(t *testing.T) {
+			p.alg, p.kid = key.alg, key.kid
+
+			result, err := runFlow(t, p, client, "")
+			if err != nil {
+				t.Fatalf("error completing flow: %v", err)
+			}
+
+			if result.Token.AccessToken != "access" {
+				t.Errorf("expected access token, got %q", result.Token.AccessToken)
+			}
+
+			if result.Claims.Subject() != "user" || result.Claims.Email() != "user@example.com" || !result.Claims.EmailVerified() {
+				t.Errorf("unexpected claims: %v", result.Claims)
+			}
+		})
+	}
+}
+
+func TestCompleteInvalidState(t *testing.T) {
+	p := newProvider(t)
+
+	_, err := runFlow(t, p, newClient(t, p), "forged")
+	if !errors.Is(err, oauth.ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
+
+func TestCompleteInvalidIDToken(t *testing.T) {
+	tests := map[string]struct {
+		alg    string
+		kid    string
+		claims map[string]any
+	}{
+		"audience":       {claims: map[string]any{"aud": "other"}},
+		"issuer":         {claims: map[string]any{"iss": "https://attacker.example.com"}},
+		"expired":        {claims: map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}},
+		"missing expiry": {claims: map[string]any{"exp": nil}},
+		"nonce":          {claims: map[string]any{"nonce": "replayed"}},
+		"authorized party": {
+			claims: map[string]any{"aud": []string{"client", "other"}},
+		},
+		"algorithm mismatch": {alg: "ES256", kid: "rsa"},
+		"unknown key":        {alg: "RS256", kid: "unknown"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := newProvider(t)
+			p.claims = test.claims
+
+			if test.alg != "" {
+				p.alg, p.kid = test.alg, test.kid
+			}
+
+			_, err := runFlow(t, p, newClient(t, p), "")
+			if !errors.Is(err, oauth.ErrInvalidIDToken) {
+				t.Fatalf("expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewRequiresIssuer(t *testing.T) {
+	p := newProvider(t)
+
+	_, err := oauth.New(oauth.NewOptions{
+		ClientID:  "client",
+		TokenURL:  p.server.URL + "/token",
+		JWKSURL:   p.server.URL + "/jwks",
+		Encrypter: newEncrypter(t),
+	})
+	if err == nil {
+		t.Fatal("expected an error when the issuer is empty")
+	}
+}
+
+func TestNewRequiresEncrypter(t *testing.T) {
+	p := newProvider(t)
+
+	_, err := oauth.New(oauth.NewOptions{
+		ClientID: "client",
+		TokenURL: p.server.URL + "/token",
+		JWKSURL:  p.server.URL + "/jwks",
+		Issuer:   p.server.URL,
+	})
+	if err == nil {
+		t.Fatal("expected an error when the encrypter is nil")
+	}
+}
+
+func TestExchangeError(t *testing.T) {
+	p := newProvider(t)
+
+	_, err := newClient(t, p).Exchange(context.Background(), "wrong", "verifier")
+	if !errors.Is(err, oauth.ErrTokenExchange) {
+		t.Fatalf("expected ErrTokenExchange, got %v", err)
+	}
+}
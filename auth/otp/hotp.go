@@ -0,0 +1,87 @@
+package otp
+
+import (
+	"crypto/subtle"
+	"hash"
+)
+
+// HOTP generates and verifies counter based one-time passwords as described in
+// RFC 4226.
+type HOTP struct {
+	newHash   func() hash.Hash
+	algorithm Algorithm
+	digits    int
+	lookAhead uint64
+}
+
+type NewHOTPOptions struct {
+	// Algorithm is the HMAC hash function. Defaults to SHA1.
+	Algorithm Algorithm
+	// Digits is the number of digits in each code. Defaults to 6.
+	Digits int
+	// LookAhead is how many counters after the expected counter are accepted,
+	// in case the user generated codes without using them. Defaults to 0.
+	LookAhead uint64
+}
+
+// NewHOTP returns a new instance of HOTP.
+func NewHOTP(options NewHOTPOptions) (*HOTP, error) {
+	newHash, err := options.Algorithm.hash()
+	if err != nil {
+		return nil, err
+	}
+
+	digits := options.Digits
+	if digits == 0 {
+		digits = 6
+	}
+
+	err = validateDigits(digits)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := options.Algorithm
+	if algorithm == "" {
+		algorithm = SHA1
+	}
+
+	return &HOTP{
+		newHash:   newHash,
+		algorithm: algorithm,
+		digits:    digits,
+		lookAhead: options.LookAhead,
+	}, nil
+}
+
+// Code returns the code for the counter.
+func (h *HOTP) Code(secret []byte, counter uint64) string {
+	return code(h.newHash, secret, counter, h.digits)
+}
+
+// Verify reports whether the code matches the counter or one of the LookAhead
+// counters after it. When it does, it returns the counter to store for the
+// next verification, which is one past the matching counter. Codes are
+// compared in constant time.
+func (h *HOTP) Verify(secret []byte, counter uint64, otp string) (uint64, bool) {
+	for i := uint64(0); i <= h.lookAhead; i++ {
+		if subtle.ConstantTimeCompare([]byte(h.Code(secret, counter+i)), []byte(otp)) == 1 {
+			return counter + i + 1, true
+		}
+	}
+
+	return counter, false
+}
+
+// GenerateSecret returns a new random secret the size of the hash output.
+func (h *HOTP) GenerateSecret() ([]byte, error) {
+	return generateSecret(h.algorithm.secretSize())
+}
+
+// ProvisioningURI returns the otpauth:// URI for the secret. Authenticator apps
+// can import it, usually by scanning it as a QR code.
+func (h *HOTP) ProvisioningURI(issuer string, accountName string, secret []byte, counter uint64) string {
+	return provisioningURI("hotp", issuer, accountName, secret, h.algorithm, h.digits, map[string]string{
+		"counter": formatUint(counter),
+	})
+}
@@ -0,0 +1,73 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+)
+
+// Algorithm is the HMAC hash function used to generate codes.
+type Algorithm string
+
+const (
+	// SHA1 is the default algorithm and the only one supported by every
+	// authenticator app.
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+func (a Algorithm) hash() (func() hash.Hash, error) {
+	switch a {
+	case SHA1, "":
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", a)
+	}
+}
+
+// secretSize returns the recommended secret size in bytes, which is the output
+// size of the hash function.
+func (a Algorithm) secretSize() int {
+	switch a {
+	case SHA256:
+		return sha256.Size
+	case SHA512:
+		return sha512.Size
+	default:
+		return sha1.Size
+	}
+}
+
+// code generates the HOTP code for the counter as described in RFC 4226.
+func code(newHash func() hash.Hash, secret []byte, counter uint64, digits int) string {
+	mac := hmac.New(newHash, secret)
+	mac.Write(binary.BigEndian.AppendUint64(nil, counter))
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+
+	modulo := uint64(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+func validateDigits(digits int) error {
+	if digits < 6 || digits > 10 {
+		return fmt.Errorf("digits must be between 6 and 10, got %d", digits)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package otp_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth/otp"
+)
+
+// TestHOTPVectors checks the test values from RFC 4226 Appendix D.
+func TestHOTPVectors(t *testing.T) {
+	hotp, err := otp.NewHOTP(otp.NewHOTPOptions{})
+	if err != nil {
+		t.Fatalf("error creating hotp: %v", err)
+	}
+
+	secret := []byte("12345678901234567890")
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range expected {
+		if got := hotp.Code(secret, uint64(counter)); got != code {
+			t.Errorf("expected %s for counter %d, got %s", code, counter, got)
+		}
+	}
+}
+
+// TestTOTPVectors checks the test values from RFC 6238 Appendix B.
+func TestTOTPVectors(t *testing.T) {
+	secrets := map[otp.Algorithm][]byte{
+		otp.SHA1:   []byte("12345678901234567890"),
+		otp.SHA256: []byte("12345678901234567890123456789012"),
+		otp.SHA512: []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+
+	tests := []struct {
+		unix  int64
+		codes map[otp.Algorithm]string
+	}{
+		{59, map[otp.Algorithm]string{otp.SHA1: "94287082", otp.SHA256: "46119246", otp.SHA512: "90693936"}},
+		{1111111109, map[otp.Algorithm]string{otp.SHA1: "07081804", otp.SHA256: "68084774", otp.SHA512: "25091201"}},
+		{1111111111, map[otp.Algorithm]string{otp.SHA1: "14050471", otp.SHA256: "67062674", otp.SHA512: "99943326"}},
+		{1234567890, map[otp.Algorithm]string{otp.SHA1: "89005924", otp.SHA256: "91819424", otp.SHA512: "93441116"}},
+		{2000000000, map[otp.Algorithm]string{otp.SHA1: "69279037", otp.SHA256: "90698825", otp.SHA512: "38618901"}},
+		{20000000000, map[otp.Algorithm]string{otp.SHA1: "65353130", otp.SHA256: "77737706", otp.SHA512: "47863826"}},
+	}
+
+	for algorithm, secret := range secrets {
+		totp, err := otp.NewTOTP(otp.NewTOTPOptions{Algorithm: algorithm, Digits: 8})
+		if err != nil {
+			t.Fatalf("error creating totp: %v", err)
+		}
+
+		for _, test := range tests {
+			if got := totp.Code(secret, time.Unix(test.unix, 0)); got != test.codes[algorithm] {
+				t.Errorf("expected %s for %s at %d, got %s", test.codes[algorithm], algorithm, test.unix, got)
+			}
+		}
+	}
+}
+
+func TestHOTPVerifyLookAhead(t *testing.T) {
+	hotp, err := otp.NewHOTP(otp.NewHOTPOptions{LookAhead: 2})
+	if err != nil {
+		t.Fatalf("error creating hotp: %v", err)
+	}
+
+	secret := []byte("12345678901234567890")
+
+	next, ok := hotp.Verify(secret, 3, hotp.Code(secret, 5))
+	if !ok || next != 6 {
+		t.Fatalf("expected code within the look ahead to be accepted with next counter 6, got %d, %v", next, ok)
+	}
+
+	next, ok = hotp.Verify(secret, 3, hotp.Code(secret, 6))
+	if ok || next != 3 {
+		t.Fatalf("expected code past the look ahead to be rejected, got %d, %v", next, ok)
+	}
+
+	_, ok = hotp.Verify(secret, 3, hotp.Code(secret, 2))
+	if ok {
+		t.Fatalf("expected code for a used counter to be rejected")
+	}
+}
+
+func TestTOTPVerifySkew(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	secret := []byte("12345678901234567890")
+
+	totp, err := otp.NewTOTP(otp.NewTOTPOptions{Skew: 1, Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatalf("error creating totp: %v", err)
+	}
+
+	tests := map[time.Duration]bool{
+		-60 * time.Second: false,
+		-30 * time.Second: true,
+		0:                 true,
+		30 * time.Second:  true,
+		60 * time.Second:  false,
+	}
+
+	for drift, expected := range tests {
+		ok, err := totp.Verify(context.Background(), "user", secret, totp.Code(secret, now.Add(drift)))
+		if err != nil {
+			t.Fatalf("error verifying: %v", err)
+		}
+
+		if ok != expected {
+			t.Errorf("expected %v for a code %s away, got %v", expected, drift, ok)
+		}
+	}
+}
+
+func TestTOTPVerifyReplay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	secret := []byte("12345678901234567890")
+
+	totp, err := otp.NewTOTP(otp.NewTOTPOptions{
+		Skew:          1,
+		UsedStepStore: otp.NewInMemoryUsedStepStore(),
+		Now:           func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("error creating totp: %v", err)
+	}
+
+	code := totp.Code(secret, now)
+
+	ok, err := totp.Verify(context.Background(), "user", secret, code)
+	if err != nil || !ok {
+		t.Fatalf("expected code to be accepted: %v", err)
+	}
+
+	ok, err = totp.Verify(context.Background(), "user", secret, code)
+	if err != nil || ok {
+		t.Fatalf("expected replayed code to be rejected: %v", err)
+	}
+
+	ok, err = totp.Verify(context.Background(), "user", secret, totp.Code(secret, now.Add(-30*time.Second)))
+	if err != nil || ok {
+		t.Fatalf("expected code for an earlier step to be rejected: %v", err)
+	}
+
+	ok, err = totp.Verify(context.Background(), "other", secret, code)
+	if err != nil || !ok {
+		t.Fatalf("expected code to be accepted for another key: %v", err)
+	}
+}
+
+func TestNewTOTPPeriod(t *testing.T) {
+	for _, period := range []time.Duration{-30 * time.Second, 1500 * time.Millisecond} {
+		_, err := otp.NewTOTP(otp.NewTOTPOptions{Period: period})
+		if err == nil {
+			t.Errorf("expected an error for a period of %s", period)
+		}
+	}
+
+	totp, err := otp.NewTOTP(otp.NewTOTPOptions{Period: time.Minute})
+	if err != nil {
+		t.Fatalf("error creating totp: %v", err)
+	}
+
+	uri := totp.ProvisioningURI("Example", "user@example.com", []byte("12345678901234567890"))
+	if !strings.Contains(uri, "period=60") || !strings.HasPrefix(uri, "otpauth://totp/Example:user@example.com?") {
+		t.Errorf("unexpected provisioning uri: %s", uri)
+	}
+}
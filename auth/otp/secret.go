@@ -0,0 +1,115 @@
+package otp
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+)
+
+// secretEncoding is the encoding used for secrets in provisioning URIs, as
+// expected by authenticator apps.
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func generateSecret(size int) ([]byte, error) {
+	secret := make([]byte, size)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error reading random bytes: %w", err)
+	}
+
+	return secret, nil
+}
+
+// EncodeSecret returns the base32 encoding of the secret that users can type
+// into their authenticator app when they cannot scan the provisioning URI.
+func EncodeSecret(secret []byte) string {
+	return secretEncoding.EncodeToString(secret)
+}
+
+// DecodeSecret decodes a secret encoded by EncodeSecret. Lowercase letters,
+// spaces, and padding are accepted.
+func DecodeSecret(encoded string) ([]byte, error) {
+	encoded = strings.ToUpper(strings.ReplaceAll(encoded, " ", ""))
+
+	return secretEncoding.DecodeString(strings.TrimRight(encoded, "="))
+}
+
+// EncryptSecret encrypts the secret so that it can be stored. The secret is
+// bound to the key, usually the user ID, when the Encrypter implements
+// auth.AADEncrypter, so an encrypted secret cannot be copied to another user.
+func EncryptSecret(encrypter auth.Encrypter, key string, secret []byte) (string, error) {
+	if aadEncrypter, ok := encrypter.(auth.AADEncrypter); ok {
+		return aadEncrypter.EncryptWithAAD(EncodeSecret(secret), secretAdditionalData(key))
+	}
+
+	return encrypter.Encrypt(EncodeSecret(secret))
+}
+
+// DecryptSecret decrypts a secret encrypted by EncryptSecret with the same key.
+func DecryptSecret(encrypter auth.Encrypter, key string, encrypted string) ([]byte, error) {
+	var encoded string
+	var err error
+
+	if aadEncrypter, ok := encrypter.(auth.AADEncrypter); ok {
+		encoded, err = aadEncrypter.DecryptWithAAD(encrypted, secretAdditionalData(key))
+	} else {
+		encoded, err = encrypter.Decrypt(encrypted)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeSecret(encoded)
+}
+
+func secretAdditionalData(key string) string {
+	return "otp:" + key
+}
+
+func provisioningURI(
+	otpType string,
+	issuer string,
+	accountName string,
+	secret []byte,
+	algorithm Algorithm,
+	digits int,
+	extra map[string]string,
+) string {
+	label := accountName
+	if issuer != "" {
+		label = issuer + ":" + accountName
+	}
+
+	query := url.Values{}
+	query.Set("secret", EncodeSecret(secret))
+	query.Set("algorithm", string(algorithm))
+	query.Set("digits", strconv.Itoa(digits))
+
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+
+	for key, value := range extra {
+		query.Set(key, value)
+	}
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     otpType,
+		Path:     "/" + label,
+		RawQuery: query.Encode(),
+	}
+
+	return uri.String()
+}
+
+func formatUint(value uint64) string {
+	return strconv.FormatUint(value, 10)
+}
@@ -0,0 +1,138 @@
+package otp
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"time"
+)
+
+// TOTP generates and verifies time based one-time passwords as described in
+// RFC 6238.
+type TOTP struct {
+	newHash   func() hash.Hash
+	algorithm Algorithm
+	digits    int
+	period    time.Duration
+	skew      uint64
+	store     UsedStepStore
+	now       func() time.Time
+}
+
+type NewTOTPOptions struct {
+	// Algorithm is the HMAC hash function. Defaults to SHA1.
+	Algorithm Algorithm
+	// Digits is the number of digits in each code. Defaults to 6.
+	Digits int
+	// Period is how long each code is valid for. Defaults to 30 seconds.
+	Period time.Duration
+	// Skew is how many periods before and after the current period are
+	// accepted to allow for clock drift. Defaults to 0. 1 is common.
+	Skew uint64
+	// UsedStepStore records the periods that have been used so that a code
+	// cannot be used twice. Codes can be replayed when it is nil.
+	UsedStepStore UsedStepStore
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewTOTP returns a new instance of TOTP.
+func NewTOTP(options NewTOTPOptions) (*TOTP, error) {
+	newHash, err := options.Algorithm.hash()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TOTP{
+		newHash:   newHash,
+		algorithm: options.Algorithm,
+		digits:    options.Digits,
+		period:    options.Period,
+		skew:      options.Skew,
+		store:     options.UsedStepStore,
+		now:       options.Now,
+	}
+
+	if t.algorithm == "" {
+		t.algorithm = SHA1
+	}
+
+	if t.digits == 0 {
+		t.digits = 6
+	}
+
+	err = validateDigits(t.digits)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.period == 0 {
+		t.period = 30 * time.Second
+	}
+
+	if t.period < 0 || t.period%time.Second != 0 {
+		return nil, fmt.Errorf("period must be a positive whole number of seconds, got %s", t.period)
+	}
+
+	if t.now == nil {
+		t.now = time.Now
+	}
+
+	return t, nil
+}
+
+// Code returns the code for the given time.
+func (t *TOTP) Code(secret []byte, at time.Time) string {
+	return code(t.newHash, secret, t.step(at), t.digits)
+}
+
+// Verify reports whether the code is valid for the current time. The key
+// identifies whose code is being verified, usually the user ID, and is used to
+// prevent the same code from being used twice. Codes are compared in constant
+// time.
+func (t *TOTP) Verify(ctx context.Context, key string, secret []byte, otp string) (bool, error) {
+	current := int64(t.step(t.now()))
+
+	for offset := -int64(t.skew); offset <= int64(t.skew); offset++ {
+		if current+offset < 0 {
+			continue
+		}
+
+		step := uint64(current + offset)
+
+		if subtle.ConstantTimeCompare([]byte(code(t.newHash, secret, step, t.digits)), []byte(otp)) != 1 {
+			continue
+		}
+
+		if t.store == nil {
+			return true, nil
+		}
+
+		ok, err := t.store.UseStep(ctx, key, step)
+		if err != nil {
+			return false, fmt.Errorf("error recording used step: %w", err)
+		}
+
+		return ok, nil
+	}
+
+	return false, nil
+}
+
+// GenerateSecret returns a new random secret the size of the hash output.
+func (t *TOTP) GenerateSecret() ([]byte, error) {
+	return generateSecret(t.algorithm.secretSize())
+}
+
+// ProvisioningURI returns the otpauth:// URI for the secret. Authenticator apps
+// can import it, usually by scanning it as a QR code.
+func (t *TOTP) ProvisioningURI(issuer string, accountName string, secret []byte) string {
+	return provisioningURI("totp", issuer, accountName, secret, t.algorithm, t.digits, map[string]string{
+		"period": formatUint(uint64(t.period / time.Second)),
+	})
+}
+
+func (t *TOTP) step(at time.Time) uint64 {
+	return uint64(at.Unix()) / uint64(t.period/time.Second)
+}
@@ -0,0 +1,44 @@
+package otp
+
+import (
+	"context"
+	"sync"
+)
+
+// UsedStepStore records which TOTP time steps have been used so that a code
+// cannot be replayed while it is still valid. It is up to you to implement
+// this interface for your specific use case, such as a column on the users
+// table.
+type UsedStepStore interface {
+	// UseStep records that the step was used for the key. It must return false
+	// when the step or a later step was already used for the key. It must be
+	// atomic so that two requests cannot use the same step.
+	UseStep(ctx context.Context, key string, step uint64) (bool, error)
+}
+
+// InMemoryUsedStepStore is an in-memory implementation of the UsedStepStore
+// interface. It only prevents replays within a single process.
+type InMemoryUsedStepStore struct {
+	mu    sync.Mutex
+	steps map[string]uint64
+}
+
+// NewInMemoryUsedStepStore returns a new instance of InMemoryUsedStepStore.
+func NewInMemoryUsedStepStore() UsedStepStore {
+	return &InMemoryUsedStepStore{
+		steps: map[string]uint64{},
+	}
+}
+
+func (s *InMemoryUsedStepStore) UseStep(ctx context.Context, key string, step uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, found := s.steps[key]; found && step <= last {
+		return false, nil
+	}
+
+	s.steps[key] = step
+
+	return true, nil
+}
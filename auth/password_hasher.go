@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"context"
+)
+
+// PasswordHasher is responsible for hashing and verifying passwords. Hashes
+// should be self describing strings, such as PHC strings, that contain the
+// algorithm and parameters used so that they can be verified after the
+// parameters change. The following link provides implementations.
+//
+//   - [PasswordHasher](./passwords)
+type PasswordHasher interface {
+	// Hash returns the hash of the given password. An error is returned when
+	// the context is cancelled before the password is hashed.
+	Hash(ctx context.Context, password string) (string, error)
+	// Verify reports whether the password matches the hash. The comparison is
+	// done in constant time. An error is returned when the hash is invalid or
+	// the context is cancelled before the password is verified.
+	Verify(ctx context.Context, password string, hash string) (bool, error)
+	// NeedsRehash reports whether the hash was created with a different
+	// algorithm or parameters than Hash currently uses. Rehash the password
+	// after it has been verified when this is true.
+	NeedsRehash(hash string) bool
+}
@@ -0,0 +1,26 @@
+package passwordpolicy
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+)
+
+// BreachChecker looks up how many times a password has appeared in data
+// breaches, such as the Have I Been Pwned Pwned Passwords dataset. It is up to
+// you to choose between the FileBreachChecker, which keeps passwords on the
+// machine, and the HTTPBreachChecker, which uses the k-anonymity API.
+type BreachChecker interface {
+	// BreachCount returns the number of times the password has appeared in
+	// data breaches. It returns 0 when the password has not been breached.
+	BreachCount(ctx context.Context, password string) (int, error)
+}
+
+// sha1Hex returns the uppercase hex SHA-1 of the password, which is how
+// passwords are identified in the Pwned Passwords dataset.
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,132 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FileBreachChecker is an implementation of the BreachChecker interface that
+// looks passwords up in a local copy of the Pwned Passwords dataset. The file
+// must contain one HASH:COUNT line per password sorted by the uppercase hex
+// SHA-1 hash, which is the format produced by the official Pwned Passwords
+// downloader. The file is binary searched on disk, so it is never loaded into
+// memory and lookups take O(log n) reads.
+type FileBreachChecker struct {
+	file *os.File
+	size int64
+}
+
+var _ BreachChecker = &FileBreachChecker{}
+
+// NewFileBreachChecker opens the file at the given path. Call Close when the
+// FileBreachChecker is no longer needed.
+func NewFileBreachChecker(path string) (*FileBreachChecker, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	return &FileBreachChecker{
+		file: file,
+		size: info.Size(),
+	}, nil
+}
+
+// Close closes the file.
+func (c *FileBreachChecker) Close() error {
+	return c.file.Close()
+}
+
+func (c *FileBreachChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	hash := sha1Hex(password)
+
+	// The line for the hash, if there is one, always starts in [low, high).
+	low, high := int64(0), c.size
+
+	for low < high {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		mid := low + (high-low)/2
+
+		line, end, err := c.firstLineAfter(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if end == -1 {
+			high = mid
+
+			continue
+		}
+
+		lineHash, count, _ := strings.Cut(line, ":")
+
+		switch strings.Compare(strings.ToUpper(lineHash), hash) {
+		case 0:
+			return parseCount(count)
+		case -1:
+			low = end
+		default:
+			high = mid
+		}
+	}
+
+	return 0, nil
+}
+
+// firstLineAfter returns the first line that starts at or after the offset and
+// the offset of the line after it. The returned offset is -1 when no line
+// starts at or after the offset.
+func (c *FileBreachChecker) firstLineAfter(offset int64) (string, int64, error) {
+	start := offset
+
+	if offset > 0 {
+		reader := bufio.NewReader(io.NewSectionReader(c.file, offset-1, c.size-offset+1))
+
+		skipped, err := reader.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			return "", -1, nil
+		} else if err != nil {
+			return "", -1, err
+		}
+
+		start = offset - 1 + int64(len(skipped))
+	}
+
+	if start >= c.size {
+		return "", -1, nil
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(c.file, start, c.size-start))
+
+	line, err := reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", -1, err
+	}
+
+	return strings.TrimRight(line, "\r\n"), start + int64(len(line)), nil
+}
+
+func parseCount(count string) (int, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil {
+		return 0, fmt.Errorf("invalid breach count %q: %w", count, err)
+	}
+
+	return value, nil
+}
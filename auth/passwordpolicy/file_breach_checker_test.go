@@ -0,0 +1,125 @@
+package passwordpolicy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/lukeshay/g/auth/passwordpolicy"
+)
+
+// newBreachFile writes the lines to a file sorted like the Pwned Passwords
+// dataset and opens a FileBreachChecker for it.
+func newBreachFile(t *testing.T, lines []string) *passwordpolicy.FileBreachChecker {
+	t.Helper()
+
+	sort.Slice(lines, func(i, j int) bool {
+		return strings.ToUpper(lines[i]) < strings.ToUpper(lines[j])
+	})
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")), 0o600)
+	if err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	checker, err := passwordpolicy.NewFileBreachChecker(path)
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	t.Cleanup(func() {
+		checker.Close()
+	})
+
+	return checker
+}
+
+func TestFileBreachChecker(t *testing.T) {
+	counts := map[string]int{}
+	lines := []string{}
+
+	for i := 0; i < 500; i++ {
+		password := fmt.Sprintf("password%d", i)
+		counts[password] = i + 1
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(password), i+1))
+	}
+
+	// Hashes are matched case insensitively.
+	counts["lowercase"] = 7
+	lines = append(lines, fmt.Sprintf("%s:7", strings.ToLower(sha1Hex("lowercase"))))
+
+	checker := newBreachFile(t, lines)
+
+	for password, expected := range counts {
+		count, err := checker.BreachCount(context.Background(), password)
+		if err != nil {
+			t.Fatalf("error checking %q: %v", password, err)
+		}
+
+		if count != expected {
+			t.Fatalf("expected %q to be breached %d times, got %d", password, expected, count)
+		}
+	}
+
+	for _, password := range []string{"correct horse battery staple", "", "password500"} {
+		count, err := checker.BreachCount(context.Background(), password)
+		if err != nil {
+			t.Fatalf("error checking %q: %v", password, err)
+		}
+
+		if count != 0 {
+			t.Fatalf("expected %q not to be breached, got %d", password, count)
+		}
+	}
+}
+
+func TestFileBreachCheckerMalformedLines(t *testing.T) {
+	checker := newBreachFile(t, []string{
+		sha1Hex("password") + ":many",
+		sha1Hex("letmein") + ":3",
+		"not a hash",
+		"",
+	})
+
+	_, err := checker.BreachCount(context.Background(), "password")
+	if err == nil {
+		t.Fatalf("expected an error for an invalid count")
+	}
+
+	count, err := checker.BreachCount(context.Background(), "letmein")
+	if err != nil || count != 3 {
+		t.Fatalf("expected 3 breaches, got %d: %v", count, err)
+	}
+
+	count, err = checker.BreachCount(context.Background(), "correct horse battery staple")
+	if err != nil || count != 0 {
+		t.Fatalf("expected 0 breaches, got %d: %v", count, err)
+	}
+}
+
+func TestFileBreachCheckerEmptyFile(t *testing.T) {
+	checker := newBreachFile(t, []string{})
+
+	count, err := checker.BreachCount(context.Background(), "password")
+	if err != nil || count != 0 {
+		t.Fatalf("expected 0 breaches, got %d: %v", count, err)
+	}
+}
+
+func TestFileBreachCheckerContext(t *testing.T) {
+	checker := newBreachFile(t, []string{sha1Hex("password") + ":1"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := checker.BreachCount(ctx, "password")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultRangeURL is the Pwned Passwords range API.
+const DefaultRangeURL = "https://api.pwnedpasswords.com/range/"
+
+// HTTPBreachChecker is an implementation of the BreachChecker interface that
+// uses the Pwned Passwords k-anonymity range API. Only the first 5 characters
+// of the SHA-1 hash of the password are sent and responses are padded, so
+// neither the password nor its hash leave the process.
+type HTTPBreachChecker struct {
+	client   *http.Client
+	rangeURL string
+}
+
+var _ BreachChecker = &HTTPBreachChecker{}
+
+type NewHTTPBreachCheckerOptions struct {
+	// Client is used to make the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+	// RangeURL is the URL the hash prefix is appended to. Defaults to
+	// DefaultRangeURL.
+	RangeURL string
+}
+
+// NewHTTPBreachChecker returns a new instance of HTTPBreachChecker.
+func NewHTTPBreachChecker(options NewHTTPBreachCheckerOptions) *HTTPBreachChecker {
+	c := &HTTPBreachChecker{
+		client:   options.Client,
+		rangeURL: options.RangeURL,
+	}
+
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+
+	if c.rangeURL == "" {
+		c.rangeURL = DefaultRangeURL
+	}
+
+	return c
+}
+
+func (c *HTTPBreachChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	hash := sha1Hex(password)
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.rangeURL+prefix, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Add-Padding", "true")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		lineSuffix, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if found && strings.EqualFold(lineSuffix, suffix) {
+			return parseCount(count)
+		}
+	}
+
+	return 0, scanner.Err()
+}
@@ -0,0 +1,122 @@
+package passwordpolicy_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lukeshay/g/auth/passwordpolicy"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// newRangeServer returns a stub of the Pwned Passwords range API that knows
+// the given breach counts. Every response is padded with a zero count entry
+// like the real API.
+func newRangeServer(t *testing.T, counts map[string]int) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	requests := []string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := strings.TrimPrefix(r.URL.Path, "/range/")
+		requests = append(requests, prefix)
+
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Errorf("expected Add-Padding header")
+		}
+
+		for password, count := range counts {
+			hash := sha1Hex(password)
+			if hash[:5] == prefix {
+				fmt.Fprintf(w, "%s:%d\r\n", hash[5:], count)
+			}
+		}
+
+		fmt.Fprintf(w, "%s:0\r\n", strings.Repeat("0", 35))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestHTTPBreachChecker(t *testing.T) {
+	server, requests := newRangeServer(t, map[string]int{"password123": 42})
+
+	checker := passwordpolicy.NewHTTPBreachChecker(passwordpolicy.NewHTTPBreachCheckerOptions{
+		Client:   server.Client(),
+		RangeURL: server.URL + "/range/",
+	})
+
+	count, err := checker.BreachCount(context.Background(), "password123")
+	if err != nil {
+		t.Fatalf("error checking password: %v", err)
+	}
+
+	if count != 42 {
+		t.Errorf("expected 42 breaches, got %d", count)
+	}
+
+	count, err = checker.BreachCount(context.Background(), "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("error checking password: %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("expected 0 breaches, got %d", count)
+	}
+
+	for _, prefix := range *requests {
+		if len(prefix) != 5 {
+			t.Errorf("expected only the 5 character hash prefix to be sent, got %q", prefix)
+		}
+	}
+}
+
+func TestHTTPBreachCheckerStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	checker := passwordpolicy.NewHTTPBreachChecker(passwordpolicy.NewHTTPBreachCheckerOptions{
+		Client:   server.Client(),
+		RangeURL: server.URL + "/range/",
+	})
+
+	_, err := checker.BreachCount(context.Background(), "password123")
+	if err == nil {
+		t.Fatalf("expected an error for an unexpected status code")
+	}
+}
+
+func TestPolicyWithHTTPBreachChecker(t *testing.T) {
+	server, _ := newRangeServer(t, map[string]int{"password123": 42})
+
+	policy := passwordpolicy.New(passwordpolicy.NewOptions{
+		BreachChecker: passwordpolicy.NewHTTPBreachChecker(passwordpolicy.NewHTTPBreachCheckerOptions{
+			Client:   server.Client(),
+			RangeURL: server.URL + "/range/",
+		}),
+	})
+
+	err := policy.Check(context.Background(), "password123")
+	if !errors.Is(err, passwordpolicy.ErrPasswordBreached) {
+		t.Fatalf("expected ErrPasswordBreached, got %v", err)
+	}
+
+	err = policy.Check(context.Background(), "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("expected password to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package passwordpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// These errors are returned by Policy.Check so that callers can tell the user
+// why their password was rejected with errors.Is.
+var (
+	ErrPasswordTooShort          = errors.New("password is too short")
+	ErrPasswordTooLong           = errors.New("password is too long")
+	ErrPasswordMissingCharacters = errors.New("password is missing required characters")
+	ErrPasswordBreached          = errors.New("password has appeared in a data breach")
+)
+
+// Policy checks that passwords are strong enough before they are hashed and
+// stored. The defaults follow The Copenhagen Book: passwords must be at least 8
+// characters, long passwords are allowed, no character classes are required,
+// and passwords that have appeared in data breaches are rejected.
+type Policy struct {
+	minLength      int
+	maxLength      int
+	requireLower   bool
+	requireUpper   bool
+	requireDigit   bool
+	requireSymbol  bool
+	breachChecker  BreachChecker
+	maxBreachCount int
+}
+
+type NewOptions struct {
+	// MinLength is the minimum number of characters. Defaults to 8.
+	MinLength int
+	// MaxLength is the maximum number of characters. Defaults to 256.
+	MaxLength int
+	// RequireLower requires at least one lowercase letter.
+	RequireLower bool
+	// RequireUpper requires at least one uppercase letter.
+	RequireUpper bool
+	// RequireDigit requires at least one digit.
+	RequireDigit bool
+	// RequireSymbol requires at least one character that is not a letter or a
+	// digit.
+	RequireSymbol bool
+	// BreachChecker is used to look up whether the password has appeared in a
+	// data breach. Breached passwords are not checked when it is nil.
+	BreachChecker BreachChecker
+	// MaxBreachCount is the number of times a password may have appeared in
+	// data breaches before it is rejected. Defaults to 0, so any breached
+	// password is rejected.
+	MaxBreachCount int
+}
+
+// New returns a new instance of Policy.
+func New(options NewOptions) *Policy {
+	p := &Policy{
+		minLength:      options.MinLength,
+		maxLength:      options.MaxLength,
+		requireLower:   options.RequireLower,
+		requireUpper:   options.RequireUpper,
+		requireDigit:   options.RequireDigit,
+		requireSymbol:  options.RequireSymbol,
+		breachChecker:  options.BreachChecker,
+		maxBreachCount: options.MaxBreachCount,
+	}
+
+	if p.minLength == 0 {
+		p.minLength = 8
+	}
+
+	if p.maxLength == 0 {
+		p.maxLength = 256
+	}
+
+	return p
+}
+
+// Check returns an error when the password does not satisfy the policy. The
+// rules are checked before the BreachChecker so that obviously weak passwords
+// never leave the process.
+func (p *Policy) Check(ctx context.Context, password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrPasswordTooShort, p.minLength)
+	}
+
+	if length > p.maxLength {
+		return fmt.Errorf("%w: must be at most %d characters", ErrPasswordTooLong, p.maxLength)
+	}
+
+	err := p.checkCharacters(password)
+	if err != nil {
+		return err
+	}
+
+	if p.breachChecker == nil {
+		return nil
+	}
+
+	count, err := p.breachChecker.BreachCount(ctx, password)
+	if err != nil {
+		return fmt.Errorf("error checking password breaches: %w", err)
+	}
+
+	if count > p.maxBreachCount {
+		return fmt.Errorf("%w: seen %d times", ErrPasswordBreached, count)
+	}
+
+	return nil
+}
+
+func (p *Policy) checkCharacters(password string) error {
+	hasLower, hasUpper, hasDigit, hasSymbol := false, false, false, false
+
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case !unicode.IsLetter(c):
+			hasSymbol = true
+		}
+	}
+
+	if p.requireLower && !hasLower {
+		return fmt.Errorf("%w: must contain a lowercase letter", ErrPasswordMissingCharacters)
+	}
+
+	if p.requireUpper && !hasUpper {
+		return fmt.Errorf("%w: must contain an uppercase letter", ErrPasswordMissingCharacters)
+	}
+
+	if p.requireDigit && !hasDigit {
+		return fmt.Errorf("%w: must contain a digit", ErrPasswordMissingCharacters)
+	}
+
+	if p.requireSymbol && !hasSymbol {
+		return fmt.Errorf("%w: must contain a symbol", ErrPasswordMissingCharacters)
+	}
+
+	return nil
+}
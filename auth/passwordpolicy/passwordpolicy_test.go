@@ -0,0 +1,117 @@
+package passwordpolicy_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lukeshay/g/auth/passwordpolicy"
+)
+
+// breachChecker is a BreachChecker that records the passwords it is asked
+// about.
+type breachChecker struct {
+	counts  map[string]int
+	checked []string
+}
+
+func (c *breachChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	c.checked = append(c.checked, password)
+
+	return c.counts[password], nil
+}
+
+func TestPolicyLength(t *testing.T) {
+	policy := passwordpolicy.New(passwordpolicy.NewOptions{})
+
+	// Lengths are counted in characters, not bytes.
+	for _, password := range []string{"12345678", "pässwörd", strings.Repeat("a", 256)} {
+		err := policy.Check(context.Background(), password)
+		if err != nil {
+			t.Errorf("expected %q to be accepted, got %v", password, err)
+		}
+	}
+
+	err := policy.Check(context.Background(), "1234567")
+	if !errors.Is(err, passwordpolicy.ErrPasswordTooShort) {
+		t.Errorf("expected ErrPasswordTooShort, got %v", err)
+	}
+
+	err = policy.Check(context.Background(), strings.Repeat("a", 257))
+	if !errors.Is(err, passwordpolicy.ErrPasswordTooLong) {
+		t.Errorf("expected ErrPasswordTooLong, got %v", err)
+	}
+
+	policy = passwordpolicy.New(passwordpolicy.NewOptions{MinLength: 12, MaxLength: 16})
+
+	err = policy.Check(context.Background(), "12345678901")
+	if !errors.Is(err, passwordpolicy.ErrPasswordTooShort) {
+		t.Errorf("expected ErrPasswordTooShort, got %v", err)
+	}
+
+	err = policy.Check(context.Background(), "12345678901234567")
+	if !errors.Is(err, passwordpolicy.ErrPasswordTooLong) {
+		t.Errorf("expected ErrPasswordTooLong, got %v", err)
+	}
+}
+
+func TestPolicyCharacters(t *testing.T) {
+	policy := passwordpolicy.New(passwordpolicy.NewOptions{
+		RequireLower:  true,
+		RequireUpper:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	})
+
+	for _, password := range []string{"ABCDEFG1!", "abcdefg1!", "abcdEFGH!", "abcdEFG12"} {
+		err := policy.Check(context.Background(), password)
+		if !errors.Is(err, passwordpolicy.ErrPasswordMissingCharacters) {
+			t.Errorf("expected ErrPasswordMissingCharacters for %q, got %v", password, err)
+		}
+	}
+
+	err := policy.Check(context.Background(), "abcdEFG1!")
+	if err != nil {
+		t.Errorf("expected password to be accepted, got %v", err)
+	}
+}
+
+func TestPolicyBreaches(t *testing.T) {
+	checker := &breachChecker{counts: map[string]int{"password123": 3}}
+
+	policy := passwordpolicy.New(passwordpolicy.NewOptions{BreachChecker: checker})
+
+	err := policy.Check(context.Background(), "password123")
+	if !errors.Is(err, passwordpolicy.ErrPasswordBreached) {
+		t.Fatalf("expected ErrPasswordBreached, got %v", err)
+	}
+
+	err = policy.Check(context.Background(), "short")
+	if !errors.Is(err, passwordpolicy.ErrPasswordTooShort) {
+		t.Fatalf("expected ErrPasswordTooShort, got %v", err)
+	}
+
+	if len(checker.checked) != 1 {
+		t.Errorf("expected passwords that break the rules not to be looked up, got %v", checker.checked)
+	}
+
+	policy = passwordpolicy.New(passwordpolicy.NewOptions{BreachChecker: checker, MaxBreachCount: 3})
+
+	err = policy.Check(context.Background(), "password123")
+	if err != nil {
+		t.Fatalf("expected password within MaxBreachCount to be accepted, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = policy.Check(ctx, "password123")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
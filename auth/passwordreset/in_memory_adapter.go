@@ -0,0 +1,88 @@
+package passwordreset
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemoryAdapter is an in-memory implementation of the Adapter interface.
+type InMemoryAdapter struct {
+	mu     sync.Mutex
+	tokens map[string]ResetToken
+}
+
+// NewInMemoryAdapter returns a new instance of InMemoryAdapter.
+func NewInMemoryAdapter() Adapter {
+	return &InMemoryAdapter{
+		tokens: map[string]ResetToken{},
+	}
+}
+
+func (a *InMemoryAdapter) InsertToken(ctx context.Context, token ResetToken) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.tokens[token.TokenHash] = token
+
+	return nil
+}
+
+func (a *InMemoryAdapter) GetToken(ctx context.Context, tokenHash string) (ResetToken, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	token, found := a.tokens[tokenHash]
+	if !found {
+		return ResetToken{}, ErrTokenNotFound
+	}
+
+	return token, nil
+}
+
+func (a *InMemoryAdapter) ConsumeToken(ctx context.Context, tokenHash string) (ResetToken, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	token, found := a.tokens[tokenHash]
+	if !found {
+		return ResetToken{}, ErrTokenNotFound
+	}
+
+	delete(a.tokens, tokenHash)
+
+	return token, nil
+}
+
+func (a *InMemoryAdapter) GetLatestToken(ctx context.Context, userID string) (ResetToken, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var latestToken ResetToken
+	found := false
+
+	for _, token := range a.tokens {
+		if token.UserID == userID && (!found || token.CreatedAt.After(latestToken.CreatedAt)) {
+			latestToken = token
+			found = true
+		}
+	}
+
+	if !found {
+		return ResetToken{}, ErrTokenNotFound
+	}
+
+	return latestToken, nil
+}
+
+func (a *InMemoryAdapter) DeleteTokensByUserID(ctx context.Context, userID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for tokenHash, token := range a.tokens {
+		if token.UserID == userID {
+			delete(a.tokens, tokenHash)
+		}
+	}
+
+	return nil
+}
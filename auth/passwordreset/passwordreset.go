@@ -0,0 +1,304 @@
+package passwordreset
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/generators"
+	"github.com/lukeshay/g/auth/passwordpolicy"
+	"github.com/lukeshay/g/auth/verification"
+)
+
+var (
+	// ErrTokenNotFound is returned when a token does not exist or has already
+	// been used. Adapters should return this error when a token cannot be
+	// found.
+	ErrTokenNotFound = errors.New("password reset token not found")
+	// ErrTokenExpired is returned when a token exists but has expired.
+	ErrTokenExpired = errors.New("password reset token is expired")
+	// ErrUserNotFound should be returned by LookupUser when no user has the
+	// email address.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrResendThrottled is returned when a reset is requested before
+	// ResendInterval has passed since the previous token was sent.
+	ErrResendThrottled = errors.New("password reset token was sent too recently")
+)
+
+// ResetToken is a token that allows a user to set a new password. Only the hash
+// of the token is stored.
+type ResetToken struct {
+	TokenHash string
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Adapter is responsible for storing password reset tokens in a datastore of
+// your choice.
+type Adapter interface {
+	// InsertToken inserts a new token.
+	InsertToken(ctx context.Context, token ResetToken) error
+	// GetToken returns the token with the given hash. It returns
+	// ErrTokenNotFound when the token does not exist.
+	GetToken(ctx context.Context, tokenHash string) (ResetToken, error)
+	// ConsumeToken deletes the token with the given hash and returns it. It
+	// must be atomic so that a token can only be consumed once, even by
+	// concurrent requests. It returns ErrTokenNotFound when the token does not
+	// exist.
+	ConsumeToken(ctx context.Context, tokenHash string) (ResetToken, error)
+	// GetLatestToken returns the most recently created token for the user. It
+	// returns ErrTokenNotFound when the user does not have a token.
+	GetLatestToken(ctx context.Context, userID string) (ResetToken, error)
+	// DeleteTokensByUserID deletes all of the tokens for the user.
+	DeleteTokensByUserID(ctx context.Context, userID string) error
+}
+
+// LookupUser returns the ID of the user with the email address or
+// ErrUserNotFound.
+type LookupUser func(ctx context.Context, email string) (string, error)
+
+// UpdatePasswordHash stores the user's new password hash.
+type UpdatePasswordHash func(ctx context.Context, userID string, passwordHash string) error
+
+// NewSession returns the session created for the user after their password is
+// reset. The session ID is set by the SessionService.
+type NewSession func(userID string) auth.Session
+
+// Service emails short lived password reset tokens and resets passwords with
+// them. Resetting a password signs the user out of every session and creates a
+// new one.
+type Service struct {
+	adapter            Adapter
+	sessionService     *auth.SessionService
+	passwordHasher     auth.PasswordHasher
+	passwordPolicy     *passwordpolicy.Policy
+	mailer             verification.Mailer
+	compose            verification.Compose
+	lookupUser         LookupUser
+	updatePasswordHash UpdatePasswordHash
+	newSession         NewSession
+	generator          auth.Generator
+	hasher             auth.Hasher
+	ttl                time.Duration
+	resendInterval     time.Duration
+	now                func() time.Time
+}
+
+type NewOptions struct {
+	Adapter            Adapter
+	SessionService     *auth.SessionService
+	PasswordHasher     auth.PasswordHasher
+	Mailer             verification.Mailer
+	LookupUser         LookupUser
+	UpdatePasswordHash UpdatePasswordHash
+	NewSession         NewSession
+	// Compose builds the message sent to the user. It should link to the
+	// reset page with the token. Defaults to a message containing just the
+	// token.
+	Compose verification.Compose
+	// PasswordPolicy checks the new password. Passwords are not checked when
+	// it is nil.
+	PasswordPolicy *passwordpolicy.Policy
+	// Generator generates the tokens. Defaults to 20 random bytes encoded as
+	// lowercase base32.
+	Generator auth.Generator
+	// Hasher hashes the tokens before they are stored. Defaults to SHA-256.
+	Hasher auth.Hasher
+	// TTL is how long a token is valid for. Defaults to 1 hour.
+	TTL time.Duration
+	// ResendInterval is the minimum time between two tokens for the same
+	// user. Defaults to 1 minute.
+	ResendInterval time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// New returns a new instance of Service.
+func New(options NewOptions) *Service {
+	s := &Service{
+		adapter:            options.Adapter,
+		sessionService:     options.SessionService,
+		passwordHasher:     options.PasswordHasher,
+		passwordPolicy:     options.PasswordPolicy,
+		mailer:             options.Mailer,
+		compose:            options.Compose,
+		lookupUser:         options.LookupUser,
+		updatePasswordHash: options.UpdatePasswordHash,
+		newSession:         options.NewSession,
+		generator:          options.Generator,
+		hasher:             options.Hasher,
+		ttl:                options.TTL,
+		resendInterval:     options.ResendInterval,
+		now:                options.Now,
+	}
+
+	if s.compose == nil {
+		s.compose = defaultCompose
+	}
+
+	if s.generator == nil {
+		s.generator = generators.NewBase32LowerGenerator(20)
+	}
+
+	if s.hasher == nil {
+		s.hasher = auth.NewSha256Hasher()
+	}
+
+	if s.ttl == 0 {
+		s.ttl = time.Hour
+	}
+
+	if s.resendInterval == 0 {
+		s.resendInterval = time.Minute
+	}
+
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	return s
+}
+
+// RequestReset emails a reset token to the user with the email address. Tokens
+// the user was sent before are deleted. Nothing is sent and no error is
+// returned when no user has the email address, so the result does not reveal
+// which addresses have accounts. It returns an error wrapping
+// ErrResendThrottled when the previous token was sent less than
+// ResendInterval ago, do not show it to the client for the same reason.
+func (s *Service) RequestReset(ctx context.Context, email string) error {
+	userID, err := s.lookupUser(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error looking up user: %w", err)
+	}
+
+	now := s.now()
+
+	latestToken, err := s.adapter.GetLatestToken(ctx, userID)
+	if err == nil && now.Before(latestToken.CreatedAt.Add(s.resendInterval)) {
+		return fmt.Errorf("%w: try again in %s", ErrResendThrottled, latestToken.CreatedAt.Add(s.resendInterval).Sub(now).Round(time.Second))
+	} else if err != nil && !errors.Is(err, ErrTokenNotFound) {
+		return fmt.Errorf("error getting latest password reset token: %w", err)
+	}
+
+	token, err := s.generator.Generate()
+	if err != nil {
+		return fmt.Errorf("error generating password reset token: %w", err)
+	}
+
+	err = s.adapter.DeleteTokensByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting password reset tokens: %w", err)
+	}
+
+	err = s.adapter.InsertToken(ctx, ResetToken{
+		TokenHash: s.hasher.Hash(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	})
+	if err != nil {
+		return fmt.Errorf("error inserting password reset token: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, s.compose(email, token))
+	if err != nil {
+		return fmt.Errorf("error sending password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateToken returns the token without consuming it. Use it to decide
+// whether to show the reset page.
+func (s *Service) ValidateToken(ctx context.Context, token string) (ResetToken, error) {
+	tokenHash := s.hasher.Hash(token)
+
+	resetToken, err := s.adapter.GetToken(ctx, tokenHash)
+	if err != nil {
+		return ResetToken{}, fmt.Errorf("error getting password reset token: %w", err)
+	}
+
+	return s.checkToken(resetToken, tokenHash)
+}
+
+// CompleteReset sets the user's password, deletes all of their sessions, and
+// returns a new session for them. The token is checked before the password so
+// that the PasswordPolicy, and any breach checker it calls, cannot be used
+// without a valid token. The token is only consumed once the new password
+// passes the PasswordPolicy, so the user can try again with a different
+// password.
+func (s *Service) CompleteReset(ctx context.Context, token string, password string) (auth.Session, error) {
+	_, err := s.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.passwordPolicy != nil {
+		err = s.passwordPolicy.Check(ctx, password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tokenHash := s.hasher.Hash(token)
+
+	resetToken, err := s.adapter.ConsumeToken(ctx, tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("error consuming password reset token: %w", err)
+	}
+
+	resetToken, err = s.checkToken(resetToken, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := s.passwordHasher.Hash(ctx, password)
+	if err != nil {
+		return nil, fmt.Errorf("error hashing password: %w", err)
+	}
+
+	err = s.updatePasswordHash(ctx, resetToken.UserID, passwordHash)
+	if err != nil {
+		return nil, fmt.Errorf("error updating password: %w", err)
+	}
+
+	err = s.adapter.DeleteTokensByUserID(ctx, resetToken.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting password reset tokens: %w", err)
+	}
+
+	err = s.sessionService.DeleteSessionsByUserID(ctx, resetToken.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting sessions: %w", err)
+	}
+
+	return s.sessionService.CreateSession(ctx, s.newSession(resetToken.UserID))
+}
+
+// checkToken compares the stored hash in constant time and checks that the
+// token has not expired.
+func (s *Service) checkToken(resetToken ResetToken, tokenHash string) (ResetToken, error) {
+	if subtle.ConstantTimeCompare([]byte(resetToken.TokenHash), []byte(tokenHash)) != 1 {
+		return ResetToken{}, ErrTokenNotFound
+	}
+
+	if resetToken.ExpiresAt.Before(s.now()) {
+		return ResetToken{}, fmt.Errorf("%w: %s", ErrTokenExpired, resetToken.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return resetToken, nil
+}
+
+func defaultCompose(email string, token string) verification.Message {
+	return verification.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Your password reset code is %s", token),
+	}
+}
@@ -0,0 +1,225 @@
+package passwordreset_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	adaptors "github.com/lukeshay/g/auth/adapters"
+	"github.com/lukeshay/g/auth/generators"
+	"github.com/lukeshay/g/auth/passwordpolicy"
+	"github.com/lukeshay/g/auth/passwordreset"
+	"github.com/lukeshay/g/auth/passwords"
+	"github.com/lukeshay/g/auth/verification"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// breachChecker is a BreachChecker that counts how often it is called.
+type breachChecker struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *breachChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+
+	return 0, nil
+}
+
+type testService struct {
+	*passwordreset.Service
+	sessionService *auth.SessionService
+	passwordHasher auth.PasswordHasher
+	mailer         *verification.InMemoryMailer
+	breachChecker  *breachChecker
+	passwordHashes map[string]string
+	now            time.Time
+}
+
+func newTestService(t *testing.T) *testService {
+	t.Helper()
+
+	s := &testService{
+		sessionService: auth.NewSessionService(auth.NewSessionServiceOptions{
+			Adapter:   adaptors.NewInMemoryAdapter(),
+			Generator: generators.NewBase32LowerGenerator(20),
+		}),
+		passwordHasher: passwords.NewBcryptHasher(passwords.NewBcryptHasherOptions{Cost: bcrypt.MinCost}),
+		mailer:         verification.NewInMemoryMailer(),
+		breachChecker:  &breachChecker{},
+		passwordHashes: map[string]string{},
+		now:            time.Now(),
+	}
+
+	s.Service = passwordreset.New(passwordreset.NewOptions{
+		Adapter:        passwordreset.NewInMemoryAdapter(),
+		SessionService: s.sessionService,
+		PasswordHasher: s.passwordHasher,
+		Mailer:         s.mailer,
+		LookupUser: func(ctx context.Context, email string) (string, error) {
+			if email != "user@example.com" {
+				return "", passwordreset.ErrUserNotFound
+			}
+
+			return "user", nil
+		},
+		UpdatePasswordHash: func(ctx context.Context, userID string, passwordHash string) error {
+			s.passwordHashes[userID] = passwordHash
+
+			return nil
+		},
+		NewSession: func(userID string) auth.Session {
+			return &adaptors.Session{UserID: userID, ExpiresAt: s.now.Add(time.Hour), RefreshUntil: s.now.Add(time.Hour)}
+		},
+		Compose: func(email string, token string) verification.Message {
+			return verification.Message{To: email, Body: token}
+		},
+		PasswordPolicy: passwordpolicy.New(passwordpolicy.NewOptions{BreachChecker: s.breachChecker}),
+		Now: func() time.Time {
+			return s.now
+		},
+	})
+
+	return s
+}
+
+// requestToken requests a reset for the user and returns the emailed token.
+func (s *testService) requestToken(t *testing.T) string {
+	t.Helper()
+
+	err := s.RequestReset(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("error requesting reset: %v", err)
+	}
+
+	messages := s.mailer.Messages()
+
+	return messages[len(messages)-1].Body
+}
+
+func TestRequestReset(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	err := s.RequestReset(ctx, "unknown@example.com")
+	if err != nil {
+		t.Fatalf("expected no error for an unknown email, got %v", err)
+	}
+
+	if len(s.mailer.Messages()) != 0 {
+		t.Fatalf("expected no message for an unknown email, got %+v", s.mailer.Messages())
+	}
+
+	token := s.requestToken(t)
+
+	resetToken, err := s.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("error validating token: %v", err)
+	}
+
+	if resetToken.UserID != "user" {
+		t.Errorf("unexpected token: %+v", resetToken)
+	}
+
+	err = s.RequestReset(ctx, "user@example.com")
+	if !errors.Is(err, passwordreset.ErrResendThrottled) {
+		t.Fatalf("expected ErrResendThrottled, got %v", err)
+	}
+
+	s.now = s.now.Add(time.Minute)
+
+	newToken := s.requestToken(t)
+
+	_, err = s.ValidateToken(ctx, token)
+	if !errors.Is(err, passwordreset.ErrTokenNotFound) {
+		t.Fatalf("expected the previous token to be deleted, got %v", err)
+	}
+
+	_, err = s.ValidateToken(ctx, newToken)
+	if err != nil {
+		t.Fatalf("error validating token: %v", err)
+	}
+}
+
+func TestCompleteReset(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	oldSession, err := s.sessionService.CreateSession(ctx, &adaptors.Session{UserID: "user", ExpiresAt: s.now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+
+	token := s.requestToken(t)
+
+	_, err = s.CompleteReset(ctx, token, "short")
+	if !errors.Is(err, passwordpolicy.ErrPasswordTooShort) {
+		t.Fatalf("expected ErrPasswordTooShort, got %v", err)
+	}
+
+	_, err = s.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("expected a rejected password not to consume the token, got %v", err)
+	}
+
+	session, err := s.CompleteReset(ctx, token, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("error completing reset: %v", err)
+	}
+
+	ok, err := s.passwordHasher.Verify(ctx, "correct horse battery staple", s.passwordHashes["user"])
+	if err != nil || !ok {
+		t.Fatalf("expected the new password hash to be stored: %v", err)
+	}
+
+	_, err = s.sessionService.GetSession(ctx, oldSession.GetSessionID())
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected the old session to be deleted, got %v", err)
+	}
+
+	newSession, err := s.sessionService.GetSession(ctx, session.GetSessionID())
+	if err != nil {
+		t.Fatalf("error getting new session: %v", err)
+	}
+
+	if newSession.GetUserID() != "user" {
+		t.Errorf("unexpected session: %+v", newSession)
+	}
+
+	_, err = s.CompleteReset(ctx, token, "correct horse battery staple")
+	if !errors.Is(err, passwordreset.ErrTokenNotFound) {
+		t.Fatalf("expected the token to be single use, got %v", err)
+	}
+}
+
+func TestCompleteResetChecksTokenFirst(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+
+	_, err := s.CompleteReset(ctx, "unknown", "correct horse battery staple")
+	if !errors.Is(err, passwordreset.ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound, got %v", err)
+	}
+
+	token := s.requestToken(t)
+	s.now = s.now.Add(time.Hour + time.Second)
+
+	_, err = s.CompleteReset(ctx, token, "correct horse battery staple")
+	if !errors.Is(err, passwordreset.ErrTokenExpired) {
+		t.Fatalf("expected ErrTokenExpired, got %v", err)
+	}
+
+	if s.breachChecker.calls != 0 {
+		t.Fatalf("expected passwords not to be checked without a valid token, got %d checks", s.breachChecker.calls)
+	}
+
+	if len(s.passwordHashes) != 0 {
+		t.Fatalf("expected no password to be set, got %v", s.passwordHashes)
+	}
+}
@@ -0,0 +1,78 @@
+package sqladapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/lukeshay/g/auth/internal/sqlmigrate"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+const (
+	// MigrationsTableName is the table Migrate uses to track which of the
+	// password reset token migrations have been applied.
+	MigrationsTableName = "auth_password_reset_migrations"
+	// MigrationLocksTableName is the table Migrate uses to make sure only one
+	// process runs the password reset token migrations at a time.
+	MigrationLocksTableName = "auth_password_reset_migration_locks"
+)
+
+// Migrations contains the versioned schema migrations for the
+// password_reset_tokens table. Migrations are never changed once released,
+// new versions are appended instead.
+var Migrations = migrate.NewMigrations()
+
+// resetTokenV1 is a snapshot of the password_reset_tokens table when it
+// was first created.
+type resetTokenV1 struct {
+	bun.BaseModel `bun:"table:password_reset_tokens"`
+
+	TokenHash string    `bun:",pk"`
+	UserID    string    `bun:",notnull"`
+	CreatedAt time.Time `bun:",notnull"`
+	ExpiresAt time.Time `bun:",notnull"`
+}
+
+func init() {
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000001",
+		Comment: "create_password_reset_tokens_table",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateTable().Model((*resetTokenV1)(nil)).IfNotExists().Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropTable().Model((*resetTokenV1)(nil)).IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000002",
+		Comment: "create_password_reset_tokens_user_id_index",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateIndex().
+				Model((*resetTokenV1)(nil)).
+				Index("password_reset_tokens_user_id_idx").
+				Column("user_id").
+				IfNotExists().
+				Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropIndex().Index("password_reset_tokens_user_id_idx").IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+}
+
+// Migrate applies all of the password reset token migrations that have not been
+// applied to the database yet.
+func Migrate(ctx context.Context, db *bun.DB) error {
+	return sqlmigrate.Run(ctx, db, Migrations, MigrationsTableName, MigrationLocksTableName)
+}
@@ -0,0 +1,139 @@
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lukeshay/g/auth/passwordreset"
+	"github.com/uptrace/bun"
+)
+
+// ResetToken is the model for the password_reset_tokens table created by
+// [Migrations].
+type ResetToken struct {
+	bun.BaseModel `bun:"table:password_reset_tokens"`
+
+	TokenHash string    `bun:",pk"`
+	UserID    string    `bun:",notnull"`
+	CreatedAt time.Time `bun:",notnull"`
+	ExpiresAt time.Time `bun:",notnull"`
+}
+
+// SQLAdapter is an implementation of the passwordreset.Adapter interface that
+// stores password reset tokens in any database supported by bun.
+type SQLAdapter struct {
+	db bun.IDB
+}
+
+type NewOptions struct {
+	// DB is the database or transaction the password reset tokens are stored
+	// in.
+	DB bun.IDB
+}
+
+// New returns a new instance of SQLAdapter.
+func New(options NewOptions) passwordreset.Adapter {
+	return &SQLAdapter{
+		db: options.DB,
+	}
+}
+
+func (a *SQLAdapter) InsertToken(ctx context.Context, token passwordreset.ResetToken) error {
+	_, err := a.db.NewInsert().Model(&ResetToken{
+		TokenHash: token.TokenHash,
+		UserID:    token.UserID,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	}).Exec(ctx)
+
+	return err
+}
+
+func (a *SQLAdapter) GetToken(ctx context.Context, tokenHash string) (passwordreset.ResetToken, error) {
+	return a.getToken(ctx, a.db, tokenHash)
+}
+
+// ConsumeToken selects and deletes the token in a transaction. The token is
+// only returned when this call deleted it, so concurrent calls cannot both
+// consume it.
+func (a *SQLAdapter) ConsumeToken(ctx context.Context, tokenHash string) (passwordreset.ResetToken, error) {
+	var token passwordreset.ResetToken
+
+	err := a.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var err error
+
+		token, err = a.getToken(ctx, tx, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.NewDelete().Model((*ResetToken)(nil)).Where("token_hash = ?", tokenHash).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if deleted == 0 {
+			return passwordreset.ErrTokenNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return passwordreset.ResetToken{}, err
+	}
+
+	return token, nil
+}
+
+func (a *SQLAdapter) GetLatestToken(ctx context.Context, userID string) (passwordreset.ResetToken, error) {
+	model := &ResetToken{}
+
+	err := a.db.NewSelect().
+		Model(model).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return passwordreset.ResetToken{}, passwordreset.ErrTokenNotFound
+	} else if err != nil {
+		return passwordreset.ResetToken{}, err
+	}
+
+	return toResetToken(model), nil
+}
+
+func (a *SQLAdapter) DeleteTokensByUserID(ctx context.Context, userID string) error {
+	_, err := a.db.NewDelete().Model((*ResetToken)(nil)).Where("user_id = ?", userID).Exec(ctx)
+
+	return err
+}
+
+func (a *SQLAdapter) getToken(ctx context.Context, db bun.IDB, tokenHash string) (passwordreset.ResetToken, error) {
+	model := &ResetToken{}
+
+	err := db.NewSelect().Model(model).Where("token_hash = ?", tokenHash).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return passwordreset.ResetToken{}, passwordreset.ErrTokenNotFound
+	} else if err != nil {
+		return passwordreset.ResetToken{}, err
+	}
+
+	return toResetToken(model), nil
+}
+
+func toResetToken(model *ResetToken) passwordreset.ResetToken {
+	return passwordreset.ResetToken{
+		TokenHash: model.TokenHash,
+		UserID:    model.UserID,
+		CreatedAt: model.CreatedAt,
+		ExpiresAt: model.ExpiresAt,
+	}
+}
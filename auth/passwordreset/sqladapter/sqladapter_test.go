@@ -0,0 +1,156 @@
+package sqladapter_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth/passwordreset"
+	"github.com/lukeshay/g/auth/passwordreset/sqladapter"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+)
+
+func newTestAdapter(t *testing.T) (passwordreset.Adapter, *bun.DB) {
+	t.Helper()
+
+	sqldb, err := sql.Open(sqliteshim.ShimName, fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	err = sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("error migrating database: %v", err)
+	}
+
+	return sqladapter.New(sqladapter.NewOptions{DB: db}), db
+}
+
+func newTestToken(tokenHash string, userID string, createdAt time.Time) passwordreset.ResetToken {
+	return passwordreset.ResetToken{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(time.Hour),
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	_, db := newTestAdapter(t)
+
+	err := sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("expected migrations to be idempotent, got %v", err)
+	}
+}
+
+func TestSQLAdapterTokens(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, token := range []passwordreset.ResetToken{
+		newTestToken("a", "user", now),
+		newTestToken("b", "user", now.Add(time.Minute)),
+		newTestToken("c", "other", now.Add(2*time.Minute)),
+	} {
+		err := adapter.InsertToken(ctx, token)
+		if err != nil {
+			t.Fatalf("error inserting token: %v", err)
+		}
+	}
+
+	token, err := adapter.GetToken(ctx, "a")
+	if err != nil {
+		t.Fatalf("error getting token: %v", err)
+	}
+
+	if token.UserID != "user" || !token.CreatedAt.Equal(now) || !token.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected token: %+v", token)
+	}
+
+	latest, err := adapter.GetLatestToken(ctx, "user")
+	if err != nil {
+		t.Fatalf("error getting latest token: %v", err)
+	}
+
+	if latest.TokenHash != "b" {
+		t.Errorf("expected the latest token to be b, got %q", latest.TokenHash)
+	}
+
+	_, err = adapter.GetLatestToken(ctx, "unknown")
+	if !errors.Is(err, passwordreset.ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound, got %v", err)
+	}
+
+	token, err = adapter.ConsumeToken(ctx, "a")
+	if err != nil || token.TokenHash != "a" {
+		t.Fatalf("expected token a to be consumed, got %+v, %v", token, err)
+	}
+
+	_, err = adapter.ConsumeToken(ctx, "a")
+	if !errors.Is(err, passwordreset.ErrTokenNotFound) {
+		t.Fatalf("expected the token to be single use, got %v", err)
+	}
+
+	err = adapter.DeleteTokensByUserID(ctx, "user")
+	if err != nil {
+		t.Fatalf("error deleting tokens: %v", err)
+	}
+
+	_, err = adapter.GetToken(ctx, "b")
+	if !errors.Is(err, passwordreset.ErrTokenNotFound) {
+		t.Fatalf("expected the user's tokens to be deleted, got %v", err)
+	}
+
+	_, err = adapter.GetToken(ctx, "c")
+	if err != nil {
+		t.Fatalf("expected the other user's token to remain, got %v", err)
+	}
+}
+
+func TestSQLAdapterConsumeTokenConcurrently(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+
+	err := adapter.InsertToken(ctx, newTestToken("a", "user", time.Now()))
+	if err != nil {
+		t.Fatalf("error inserting token: %v", err)
+	}
+
+	consumed := atomic.Int32{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := adapter.ConsumeToken(ctx, "a")
+			if err == nil {
+				consumed.Add(1)
+			} else if !errors.Is(err, passwordreset.ErrTokenNotFound) {
+				t.Errorf("error consuming token: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if consumed.Load() != 1 {
+		t.Fatalf("expected the token to be consumed once, got %d", consumed.Load())
+	}
+}
@@ -0,0 +1,153 @@
+package passwords
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2idHasher is an implementation of the PasswordHasher interface that uses
+// Argon2id. This is the recommended algorithm for new passwords. Hashes are PHC
+// strings such as $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>.
+type Argon2idHasher struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+type NewArgon2idHasherOptions struct {
+	// Memory is the memory used in KiB. Defaults to 19456 (19 MiB).
+	Memory uint32
+	// Iterations is the number of passes over the memory. Defaults to 2.
+	Iterations uint32
+	// Parallelism is the number of threads used. Defaults to 1.
+	Parallelism uint8
+	// SaltLength is the length of the random salt in bytes. Defaults to 16.
+	SaltLength uint32
+	// KeyLength is the length of the hash in bytes. Defaults to 32.
+	KeyLength uint32
+}
+
+// NewArgon2idHasher returns a new instance of Argon2idHasher. The defaults are
+// the minimum parameters recommended by OWASP.
+func NewArgon2idHasher(options NewArgon2idHasherOptions) auth.PasswordHasher {
+	h := &Argon2idHasher{
+		memory:      options.Memory,
+		iterations:  options.Iterations,
+		parallelism: options.Parallelism,
+		saltLength:  options.SaltLength,
+		keyLength:   options.KeyLength,
+	}
+
+	if h.memory == 0 {
+		h.memory = 19 * 1024
+	}
+
+	if h.iterations == 0 {
+		h.iterations = 2
+	}
+
+	if h.parallelism == 0 {
+		h.parallelism = 1
+	}
+
+	if h.saltLength == 0 {
+		h.saltLength = 16
+	}
+
+	if h.keyLength == 0 {
+		h.keyLength = 32
+	}
+
+	return h
+}
+
+func (h *Argon2idHasher) Hash(ctx context.Context, password string) (string, error) {
+	salt, err := randomSalt(h.saltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.iterations, h.memory, h.parallelism, h.keyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.memory,
+		h.iterations,
+		h.parallelism,
+		encoding.EncodeToString(salt),
+		encoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(ctx context.Context, password string, hash string) (bool, error) {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.memory != h.memory ||
+		params.iterations != h.iterations ||
+		params.parallelism != h.parallelism ||
+		uint32(len(salt)) != h.saltLength ||
+		uint32(len(key)) != h.keyLength
+}
+
+func parseArgon2id(hash string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return nil, nil, nil, fmt.Errorf("%w: not an argon2id hash", ErrInvalidHash)
+	}
+
+	var version int
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("%w: unsupported argon2 version %d", ErrInvalidHash, version)
+	}
+
+	params := &Argon2idHasher{}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	salt, err := encoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	key, err := encoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	if params.iterations == 0 || params.parallelism == 0 || len(key) == 0 {
+		return nil, nil, nil, fmt.Errorf("%w: invalid parameters", ErrInvalidHash)
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,74 @@
+package passwords
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher is an implementation of the PasswordHasher interface that uses
+// bcrypt. It is intended for verifying passwords imported from other systems,
+// use Argon2idHasher for new passwords. Hashes use the modular crypt format,
+// such as $2a$10$<salt and hash>. Passwords longer than 72 bytes are rejected.
+type BcryptHasher struct {
+	cost int
+}
+
+type NewBcryptHasherOptions struct {
+	// Cost is the bcrypt cost. Defaults to bcrypt.DefaultCost.
+	Cost int
+}
+
+// NewBcryptHasher returns a new instance of BcryptHasher.
+func NewBcryptHasher(options NewBcryptHasherOptions) auth.PasswordHasher {
+	cost := options.Cost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+
+	return &BcryptHasher{
+		cost: cost,
+	}
+}
+
+func (h *BcryptHasher) Hash(ctx context.Context, password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(ctx context.Context, password string, hash string) (bool, error) {
+	if !isBcrypt(hash) {
+		return false, fmt.Errorf("%w: not a bcrypt hash", ErrInvalidHash)
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	return true, nil
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	if !isBcrypt(hash) {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+
+	return err != nil || cost != h.cost
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
@@ -0,0 +1,71 @@
+package passwords
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lukeshay/g/auth"
+)
+
+// LimitedHasher is an implementation of the PasswordHasher interface that
+// limits how many passwords are hashed or verified at the same time. Memory
+// hard algorithms such as Argon2id allocate their memory for every call, so a
+// burst of sign ins can exhaust the memory of the process without a limit.
+// Calls over the limit wait for a slot to free up or for their context to be
+// cancelled.
+type LimitedHasher struct {
+	hasher auth.PasswordHasher
+	slots  chan struct{}
+}
+
+// NewLimitedHasher returns a new instance of LimitedHasher that allows at most
+// concurrency calls to Hash and Verify at the same time.
+func NewLimitedHasher(hasher auth.PasswordHasher, concurrency uint) auth.PasswordHasher {
+	if concurrency == 0 {
+		concurrency = 1
+	}
+
+	return &LimitedHasher{
+		hasher: hasher,
+		slots:  make(chan struct{}, concurrency),
+	}
+}
+
+func (h *LimitedHasher) Hash(ctx context.Context, password string) (string, error) {
+	err := h.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer h.release()
+
+	return h.hasher.Hash(ctx, password)
+}
+
+func (h *LimitedHasher) Verify(ctx context.Context, password string, hash string) (bool, error) {
+	err := h.acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer h.release()
+
+	return h.hasher.Verify(ctx, password, hash)
+}
+
+func (h *LimitedHasher) NeedsRehash(hash string) bool {
+	return h.hasher.NeedsRehash(hash)
+}
+
+// acquire waits for a free slot. It returns the context's error when the
+// context is cancelled first.
+func (h *LimitedHasher) acquire(ctx context.Context) error {
+	select {
+	case h.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error waiting to hash password: %w", ctx.Err())
+	}
+}
+
+func (h *LimitedHasher) release() {
+	<-h.slots
+}
@@ -0,0 +1,49 @@
+package passwords
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lukeshay/g/auth"
+)
+
+// MultiHasher is an implementation of the PasswordHasher interface that hashes
+// new passwords with one PasswordHasher and verifies hashes created by any of
+// several. Use it when importing users from another system: hashes created by
+// the legacy hashers keep working and NeedsRehash reports them so that they can
+// be upgraded the next time the user signs in.
+type MultiHasher struct {
+	hasher  auth.PasswordHasher
+	hashers []auth.PasswordHasher
+}
+
+// NewMultiHasher returns a new instance of MultiHasher. The legacy hashers must
+// return ErrInvalidHash from Verify for hashes they did not create.
+func NewMultiHasher(hasher auth.PasswordHasher, legacy ...auth.PasswordHasher) auth.PasswordHasher {
+	return &MultiHasher{
+		hasher:  hasher,
+		hashers: append([]auth.PasswordHasher{hasher}, legacy...),
+	}
+}
+
+func (h *MultiHasher) Hash(ctx context.Context, password string) (string, error) {
+	return h.hasher.Hash(ctx, password)
+}
+
+func (h *MultiHasher) Verify(ctx context.Context, password string, hash string) (bool, error) {
+	for _, hasher := range h.hashers {
+		ok, err := hasher.Verify(ctx, password, hash)
+		if errors.Is(err, ErrInvalidHash) {
+			continue
+		}
+
+		return ok, err
+	}
+
+	return false, fmt.Errorf("%w: no hasher supports the hash", ErrInvalidHash)
+}
+
+func (h *MultiHasher) NeedsRehash(hash string) bool {
+	return h.hasher.NeedsRehash(hash)
+}
@@ -0,0 +1,26 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidHash is returned when a hash is malformed or was not created by
+// the PasswordHasher verifying it.
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// encoding is the base64 encoding used for salts and hashes in PHC strings.
+var encoding = base64.RawStdEncoding
+
+func randomSalt(size uint32) ([]byte, error) {
+	salt := make([]byte, size)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("error reading random bytes: %w", err)
+	}
+
+	return salt, nil
+}
@@ -0,0 +1,233 @@
+package passwords_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/passwords"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const password = "correct horse battery staple"
+
+// The parameters are much weaker than the defaults to keep the tests fast.
+func newArgon2idHasher(iterations uint32) auth.PasswordHasher {
+	return passwords.NewArgon2idHasher(passwords.NewArgon2idHasherOptions{Memory: 64, Iterations: iterations})
+}
+
+func newScryptHasher(logN uint8) auth.PasswordHasher {
+	return passwords.NewScryptHasher(passwords.NewScryptHasherOptions{LogN: logN})
+}
+
+func newBcryptHasher(cost int) auth.PasswordHasher {
+	return passwords.NewBcryptHasher(passwords.NewBcryptHasherOptions{Cost: cost})
+}
+
+func TestHashers(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		hasher   auth.PasswordHasher
+		stronger auth.PasswordHasher
+	}{
+		"Argon2id": {hasher: newArgon2idHasher(1), stronger: newArgon2idHasher(2)},
+		"Scrypt":   {hasher: newScryptHasher(4), stronger: newScryptHasher(5)},
+		"Bcrypt":   {hasher: newBcryptHasher(bcrypt.MinCost), stronger: newBcryptHasher(bcrypt.MinCost + 1)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			hash, err := test.hasher.Hash(ctx, password)
+			if err != nil {
+				t.Fatalf("error hashing: %v", err)
+			}
+
+			otherHash, err := test.hasher.Hash(ctx, password)
+			if err != nil {
+				t.Fatalf("error hashing: %v", err)
+			}
+
+			if hash == otherHash {
+				t.Errorf("expected hashes of the same password to use different salts")
+			}
+
+			ok, err := test.hasher.Verify(ctx, password, hash)
+			if err != nil || !ok {
+				t.Fatalf("expected password to match: %v", err)
+			}
+
+			ok, err = test.hasher.Verify(ctx, "wrong password", hash)
+			if err != nil || ok {
+				t.Fatalf("expected wrong password not to match: %v", err)
+			}
+
+			ok, err = test.stronger.Verify(ctx, password, hash)
+			if err != nil || !ok {
+				t.Fatalf("expected hash to be verified with its own parameters: %v", err)
+			}
+
+			if test.hasher.NeedsRehash(hash) {
+				t.Errorf("expected hash with the current parameters not to need a rehash")
+			}
+
+			if !test.stronger.NeedsRehash(hash) {
+				t.Errorf("expected hash with other parameters to need a rehash")
+			}
+
+			_, err = test.hasher.Verify(ctx, password, "$unknown$hash")
+			if !errors.Is(err, passwords.ErrInvalidHash) {
+				t.Fatalf("expected ErrInvalidHash, got %v", err)
+			}
+
+			if !test.hasher.NeedsRehash("$unknown$hash") {
+				t.Errorf("expected unknown hash to need a rehash")
+			}
+		})
+	}
+}
+
+func TestMultiHasher(t *testing.T) {
+	ctx := context.Background()
+	bcryptHasher := newBcryptHasher(bcrypt.MinCost)
+	scryptHasher := newScryptHasher(4)
+	hasher := passwords.NewMultiHasher(newArgon2idHasher(1), bcryptHasher, scryptHasher)
+
+	for name, legacy := range map[string]auth.PasswordHasher{"Bcrypt": bcryptHasher, "Scrypt": scryptHasher} {
+		t.Run(name, func(t *testing.T) {
+			legacyHash, err := legacy.Hash(ctx, password)
+			if err != nil {
+				t.Fatalf("error hashing: %v", err)
+			}
+
+			ok, err := hasher.Verify(ctx, password, legacyHash)
+			if err != nil || !ok {
+				t.Fatalf("expected legacy hash to match: %v", err)
+			}
+
+			ok, err = hasher.Verify(ctx, "wrong password", legacyHash)
+			if err != nil || ok {
+				t.Fatalf("expected wrong password not to match: %v", err)
+			}
+
+			if !hasher.NeedsRehash(legacyHash) {
+				t.Errorf("expected legacy hash to need a rehash")
+			}
+		})
+	}
+
+	hash, err := hasher.Hash(ctx, password)
+	if err != nil {
+		t.Fatalf("error hashing: %v", err)
+	}
+
+	ok, err := newArgon2idHasher(1).Verify(ctx, password, hash)
+	if err != nil || !ok {
+		t.Fatalf("expected new hashes to use the first hasher: %v", err)
+	}
+
+	if hasher.NeedsRehash(hash) {
+		t.Errorf("expected new hash not to need a rehash")
+	}
+
+	_, err = hasher.Verify(ctx, password, "$unknown$hash")
+	if !errors.Is(err, passwords.ErrInvalidHash) {
+		t.Fatalf("expected ErrInvalidHash, got %v", err)
+	}
+}
+
+// blockingHasher records how many calls run at the same time and blocks each
+// call until release is closed.
+type blockingHasher struct {
+	auth.PasswordHasher
+	running    atomic.Int32
+	maxRunning atomic.Int32
+	started    chan struct{}
+	release    chan struct{}
+}
+
+func (h *blockingHasher) Hash(ctx context.Context, password string) (string, error) {
+	running := h.running.Add(1)
+	defer h.running.Add(-1)
+
+	for {
+		maxRunning := h.maxRunning.Load()
+		if running <= maxRunning || h.maxRunning.CompareAndSwap(maxRunning, running) {
+			break
+		}
+	}
+
+	h.started <- struct{}{}
+	<-h.release
+
+	return password, nil
+}
+
+func TestLimitedHasherConcurrency(t *testing.T) {
+	blocking := &blockingHasher{started: make(chan struct{}, 10), release: make(chan struct{})}
+	hasher := passwords.NewLimitedHasher(blocking, 2)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := hasher.Hash(context.Background(), password)
+			if err != nil {
+				t.Errorf("error hashing: %v", err)
+			}
+		}()
+	}
+
+	<-blocking.started
+	<-blocking.started
+
+	select {
+	case <-blocking.started:
+		t.Fatalf("expected at most 2 calls to run at the same time")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(blocking.release)
+	wg.Wait()
+
+	if blocking.maxRunning.Load() != 2 {
+		t.Fatalf("expected at most 2 calls to run at the same time, got %d", blocking.maxRunning.Load())
+	}
+}
+
+func TestLimitedHasherContext(t *testing.T) {
+	blocking := &blockingHasher{started: make(chan struct{}, 1), release: make(chan struct{})}
+	hasher := passwords.NewLimitedHasher(blocking, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		hasher.Hash(context.Background(), password)
+	}()
+
+	<-blocking.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := hasher.Hash(ctx, password)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	_, err = hasher.Verify(ctx, password, password)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	close(blocking.release)
+	<-done
+}
@@ -0,0 +1,147 @@
+package passwords
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ScryptHasher is an implementation of the PasswordHasher interface that uses
+// scrypt. It is intended for verifying passwords imported from other systems,
+// use Argon2idHasher for new passwords. Hashes are PHC strings such as
+// $scrypt$ln=15,r=8,p=1$<salt>$<hash>.
+type ScryptHasher struct {
+	logN       uint8
+	r          int
+	p          int
+	saltLength uint32
+	keyLength  uint32
+}
+
+type NewScryptHasherOptions struct {
+	// LogN is the base 2 logarithm of the CPU/memory cost. Defaults to 15.
+	LogN uint8
+	// R is the block size. Defaults to 8.
+	R int
+	// P is the parallelization. Defaults to 1.
+	P int
+	// SaltLength is the length of the random salt in bytes. Defaults to 16.
+	SaltLength uint32
+	// KeyLength is the length of the hash in bytes. Defaults to 32.
+	KeyLength uint32
+}
+
+// NewScryptHasher returns a new instance of ScryptHasher.
+func NewScryptHasher(options NewScryptHasherOptions) auth.PasswordHasher {
+	h := &ScryptHasher{
+		logN:       options.LogN,
+		r:          options.R,
+		p:          options.P,
+		saltLength: options.SaltLength,
+		keyLength:  options.KeyLength,
+	}
+
+	if h.logN == 0 {
+		h.logN = 15
+	}
+
+	if h.r == 0 {
+		h.r = 8
+	}
+
+	if h.p == 0 {
+		h.p = 1
+	}
+
+	if h.saltLength == 0 {
+		h.saltLength = 16
+	}
+
+	if h.keyLength == 0 {
+		h.keyLength = 32
+	}
+
+	return h
+}
+
+func (h *ScryptHasher) Hash(ctx context.Context, password string) (string, error) {
+	salt, err := randomSalt(h.saltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<h.logN, h.r, h.p, int(h.keyLength))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"$scrypt$ln=%d,r=%d,p=%d$%s$%s",
+		h.logN,
+		h.r,
+		h.p,
+		encoding.EncodeToString(salt),
+		encoding.EncodeToString(key),
+	), nil
+}
+
+func (h *ScryptHasher) Verify(ctx context.Context, password string, hash string) (bool, error) {
+	params, salt, key, err := parseScrypt(hash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey, err := scrypt.Key([]byte(password), salt, 1<<params.logN, params.r, params.p, len(key))
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+}
+
+func (h *ScryptHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := parseScrypt(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.logN != h.logN ||
+		params.r != h.r ||
+		params.p != h.p ||
+		uint32(len(salt)) != h.saltLength ||
+		uint32(len(key)) != h.keyLength
+}
+
+func parseScrypt(hash string) (*ScryptHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "scrypt" {
+		return nil, nil, nil, fmt.Errorf("%w: not a scrypt hash", ErrInvalidHash)
+	}
+
+	params := &ScryptHasher{}
+
+	_, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &params.logN, &params.r, &params.p)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	salt, err := encoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	key, err := encoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	if params.logN == 0 || params.logN > 31 || len(key) == 0 {
+		return nil, nil, nil, fmt.Errorf("%w: invalid parameters", ErrInvalidHash)
+	}
+
+	return params, salt, key, nil
+}
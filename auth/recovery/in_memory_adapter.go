@@ -0,0 +1,53 @@
+package recovery
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemoryAdapter is an in-memory implementation of the Adapter interface.
+type InMemoryAdapter struct {
+	mu    sync.Mutex
+	codes map[string]map[string]struct{}
+}
+
+// NewInMemoryAdapter returns a new instance of InMemoryAdapter.
+func NewInMemoryAdapter() Adapter {
+	return &InMemoryAdapter{
+		codes: map[string]map[string]struct{}{},
+	}
+}
+
+func (a *InMemoryAdapter) ReplaceCodes(ctx context.Context, userID string, codeHashes []string) error {
+	codes := make(map[string]struct{}, len(codeHashes))
+	for _, codeHash := range codeHashes {
+		codes[codeHash] = struct{}{}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.codes[userID] = codes
+
+	return nil
+}
+
+func (a *InMemoryAdapter) ConsumeCode(ctx context.Context, userID string, codeHash string) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, found := a.codes[userID][codeHash]; !found {
+		return false, nil
+	}
+
+	delete(a.codes[userID], codeHash)
+
+	return true, nil
+}
+
+func (a *InMemoryAdapter) CountCodes(ctx context.Context, userID string) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.codes[userID]), nil
+}
@@ -0,0 +1,122 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/generators"
+)
+
+// Adapter is responsible for storing the hashes of recovery codes in a
+// datastore of your choice. Only hashes are ever handed to the adapter.
+type Adapter interface {
+	// ReplaceCodes deletes all of the user's codes and stores the given hashes
+	// in their place.
+	ReplaceCodes(ctx context.Context, userID string, codeHashes []string) error
+	// ConsumeCode deletes the code with the given hash. It returns false when
+	// the user does not have the code. It must be atomic so that a code can
+	// only be consumed once, even by concurrent requests.
+	ConsumeCode(ctx context.Context, userID string, codeHash string) (bool, error)
+	// CountCodes returns the number of codes the user has left.
+	CountCodes(ctx context.Context, userID string) (int, error)
+}
+
+// Service issues and consumes single use recovery codes. Users can use them to
+// sign in when they lose access to their second factor.
+type Service struct {
+	adapter   Adapter
+	generator auth.Generator
+	hasher    auth.Hasher
+	count     int
+}
+
+type NewOptions struct {
+	Adapter Adapter
+	// Generator generates the codes. Defaults to 10 Crockford base32
+	// characters grouped in 5, such as ABCDE-FGHJK.
+	Generator auth.Generator
+	// Hasher hashes the codes before they are stored. Defaults to SHA-256. Codes
+	// are short, so use an HMAC Hasher to keep leaked hashes from being brute
+	// forced.
+	Hasher auth.Hasher
+	// Count is the number of codes in each batch. Defaults to 10.
+	Count int
+}
+
+// New returns a new instance of Service.
+func New(options NewOptions) *Service {
+	s := &Service{
+		adapter:   options.Adapter,
+		generator: options.Generator,
+		hasher:    options.Hasher,
+		count:     options.Count,
+	}
+
+	if s.generator == nil {
+		s.generator = generators.NewCrockfordGenerator(10, 5)
+	}
+
+	if s.hasher == nil {
+		s.hasher = auth.NewSha256Hasher()
+	}
+
+	if s.count == 0 {
+		s.count = 10
+	}
+
+	return s
+}
+
+// GenerateCodes replaces the user's codes with a new batch and returns them.
+// The codes are only returned here, so show them to the user right away.
+func (s *Service) GenerateCodes(ctx context.Context, userID string) ([]string, error) {
+	codes := make([]string, s.count)
+	codeHashes := make([]string, s.count)
+
+	for i := range codes {
+		code, err := s.generator.Generate()
+		if err != nil {
+			return nil, fmt.Errorf("error generating recovery code: %w", err)
+		}
+
+		codes[i] = code
+		codeHashes[i] = s.hasher.Hash(normalize(code))
+	}
+
+	err := s.adapter.ReplaceCodes(ctx, userID, codeHashes)
+	if err != nil {
+		return nil, fmt.Errorf("error storing recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// ConsumeCode reports whether the code is one of the user's codes and, if it
+// is, makes sure it cannot be used again. Case, spaces, and "-" are ignored.
+func (s *Service) ConsumeCode(ctx context.Context, userID string, code string) (bool, error) {
+	ok, err := s.adapter.ConsumeCode(ctx, userID, s.hasher.Hash(normalize(code)))
+	if err != nil {
+		return false, fmt.Errorf("error consuming recovery code: %w", err)
+	}
+
+	return ok, nil
+}
+
+// RemainingCodes returns the number of codes the user has left. Prompt the
+// user to generate new codes when this gets low.
+func (s *Service) RemainingCodes(ctx context.Context, userID string) (int, error) {
+	count, err := s.adapter.CountCodes(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error counting recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// normalize removes the differences users are likely to introduce when typing
+// a code.
+func normalize(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,170 @@
+package recovery_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lukeshay/g/auth/recovery"
+)
+
+func newTestService(t *testing.T) *recovery.Service {
+	t.Helper()
+
+	return recovery.New(recovery.NewOptions{Adapter: recovery.NewInMemoryAdapter()})
+}
+
+func TestGenerateCodes(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+
+	codes, err := service.GenerateCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error generating codes: %v", err)
+	}
+
+	if len(codes) != 10 {
+		t.Fatalf("expected 10 codes, got %d", len(codes))
+	}
+
+	pattern := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{5}-[0-9A-HJKMNP-TV-Z]{5}$`)
+	seen := map[string]bool{}
+
+	for _, code := range codes {
+		if !pattern.MatchString(code) {
+			t.Errorf("unexpected code format: %q", code)
+		}
+
+		if seen[code] {
+			t.Errorf("generated %q twice", code)
+		}
+
+		seen[code] = true
+	}
+
+	remaining, err := service.RemainingCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error counting codes: %v", err)
+	}
+
+	if remaining != 10 {
+		t.Fatalf("expected 10 remaining codes, got %d", remaining)
+	}
+}
+
+func TestConsumeCode(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+
+	codes, err := service.GenerateCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error generating codes: %v", err)
+	}
+
+	ok, err := service.ConsumeCode(ctx, "other", codes[0])
+	if err != nil || ok {
+		t.Fatalf("expected code not to be accepted for another user: %v", err)
+	}
+
+	// Users are likely to change the case and drop or add separators.
+	typed := " " + strings.ToLower(strings.ReplaceAll(codes[0], "-", "")) + " "
+
+	ok, err = service.ConsumeCode(ctx, "user", typed)
+	if err != nil || !ok {
+		t.Fatalf("expected code to be accepted: %v", err)
+	}
+
+	ok, err = service.ConsumeCode(ctx, "user", codes[0])
+	if err != nil || ok {
+		t.Fatalf("expected code to be single use: %v", err)
+	}
+
+	remaining, err := service.RemainingCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error counting codes: %v", err)
+	}
+
+	if remaining != 9 {
+		t.Fatalf("expected 9 remaining codes, got %d", remaining)
+	}
+}
+
+func TestConsumeCodeConcurrently(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t)
+
+	codes, err := service.GenerateCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error generating codes: %v", err)
+	}
+
+	accepted := atomic.Int32{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ok, err := service.ConsumeCode(ctx, "user", codes[0])
+			if err != nil {
+				t.Errorf("error consuming code: %v", err)
+			}
+
+			if ok {
+				accepted.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if accepted.Load() != 1 {
+		t.Fatalf("expected the code to be accepted once, got %d", accepted.Load())
+	}
+}
+
+func TestGenerateCodesReplacesCodes(t *testing.T) {
+	ctx := context.Background()
+	service := recovery.New(recovery.NewOptions{Adapter: recovery.NewInMemoryAdapter(), Count: 4})
+
+	oldCodes, err := service.GenerateCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error generating codes: %v", err)
+	}
+
+	_, err = service.ConsumeCode(ctx, "user", oldCodes[0])
+	if err != nil {
+		t.Fatalf("error consuming code: %v", err)
+	}
+
+	newCodes, err := service.GenerateCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error generating codes: %v", err)
+	}
+
+	remaining, err := service.RemainingCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error counting codes: %v", err)
+	}
+
+	if remaining != 4 {
+		t.Fatalf("expected 4 remaining codes, got %d", remaining)
+	}
+
+	for _, code := range oldCodes[1:] {
+		ok, err := service.ConsumeCode(ctx, "user", code)
+		if err != nil || ok {
+			t.Fatalf("expected old code %q to be replaced: %v", code, err)
+		}
+	}
+
+	ok, err := service.ConsumeCode(ctx, "user", newCodes[0])
+	if err != nil || !ok {
+		t.Fatalf("expected new code to be accepted: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package sqladapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/lukeshay/g/auth/internal/sqlmigrate"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+const (
+	// MigrationsTableName is the table Migrate uses to track which of the
+	// recovery code migrations have been applied.
+	MigrationsTableName = "auth_recovery_migrations"
+	// MigrationLocksTableName is the table Migrate uses to make sure only one
+	// process runs the recovery code migrations at a time.
+	MigrationLocksTableName = "auth_recovery_migration_locks"
+)
+
+// Migrations contains the versioned schema migrations for the recovery_codes
+// table. Migrations are never changed once released, new versions are
+// appended instead.
+var Migrations = migrate.NewMigrations()
+
+// recoveryCodeV1 is a snapshot of the recovery_codes table when it was first
+// created.
+type recoveryCodeV1 struct {
+	bun.BaseModel `bun:"table:recovery_codes"`
+
+	UserID    string    `bun:",pk"`
+	CodeHash  string    `bun:",pk"`
+	CreatedAt time.Time `bun:",notnull"`
+}
+
+func init() {
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000001",
+		Comment: "create_recovery_codes_table",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateTable().Model((*recoveryCodeV1)(nil)).IfNotExists().Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropTable().Model((*recoveryCodeV1)(nil)).IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+}
+
+// Migrate applies all of the recovery code migrations that have not been
+// applied to the database yet.
+func Migrate(ctx context.Context, db *bun.DB) error {
+	return sqlmigrate.Run(ctx, db, Migrations, MigrationsTableName, MigrationLocksTableName)
+}
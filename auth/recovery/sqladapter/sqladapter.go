@@ -0,0 +1,86 @@
+package sqladapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/lukeshay/g/auth/recovery"
+	"github.com/uptrace/bun"
+)
+
+// RecoveryCode is the model for the recovery_codes table created by
+// [Migrations].
+type RecoveryCode struct {
+	bun.BaseModel `bun:"table:recovery_codes"`
+
+	UserID    string    `bun:",pk"`
+	CodeHash  string    `bun:",pk"`
+	CreatedAt time.Time `bun:",notnull"`
+}
+
+// SQLAdapter is an implementation of the recovery.Adapter interface that
+// stores recovery code hashes in any database supported by bun.
+type SQLAdapter struct {
+	db bun.IDB
+}
+
+type NewOptions struct {
+	// DB is the database or transaction the recovery codes are stored in.
+	DB bun.IDB
+}
+
+// New returns a new instance of SQLAdapter.
+func New(options NewOptions) recovery.Adapter {
+	return &SQLAdapter{
+		db: options.DB,
+	}
+}
+
+func (a *SQLAdapter) ReplaceCodes(ctx context.Context, userID string, codeHashes []string) error {
+	return a.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().Model((*RecoveryCode)(nil)).Where("user_id = ?", userID).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(codeHashes) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		codes := make([]RecoveryCode, len(codeHashes))
+		for i, codeHash := range codeHashes {
+			codes[i] = RecoveryCode{
+				UserID:    userID,
+				CodeHash:  codeHash,
+				CreatedAt: now,
+			}
+		}
+
+		_, err = tx.NewInsert().Model(&codes).Exec(ctx)
+
+		return err
+	})
+}
+
+func (a *SQLAdapter) ConsumeCode(ctx context.Context, userID string, codeHash string) (bool, error) {
+	result, err := a.db.NewDelete().
+		Model((*RecoveryCode)(nil)).
+		Where("user_id = ?", userID).
+		Where("code_hash = ?", codeHash).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
+func (a *SQLAdapter) CountCodes(ctx context.Context, userID string) (int, error) {
+	return a.db.NewSelect().Model((*RecoveryCode)(nil)).Where("user_id = ?", userID).Count(ctx)
+}
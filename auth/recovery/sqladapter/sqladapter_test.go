@@ -0,0 +1,173 @@
+package sqladapter_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lukeshay/g/auth/recovery"
+	"github.com/lukeshay/g/auth/recovery/sqladapter"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+)
+
+func newTestAdapter(t *testing.T) (recovery.Adapter, *bun.DB) {
+	t.Helper()
+
+	sqldb, err := sql.Open(sqliteshim.ShimName, fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	err = sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("error migrating database: %v", err)
+	}
+
+	return sqladapter.New(sqladapter.NewOptions{DB: db}), db
+}
+
+func TestMigrate(t *testing.T) {
+	_, db := newTestAdapter(t)
+
+	err := sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("expected migrations to be idempotent, got %v", err)
+	}
+}
+
+func TestSQLAdapterConsumeCode(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+
+	err := adapter.ReplaceCodes(ctx, "user", []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("error replacing codes: %v", err)
+	}
+
+	err = adapter.ReplaceCodes(ctx, "other", []string{"a"})
+	if err != nil {
+		t.Fatalf("error replacing codes: %v", err)
+	}
+
+	ok, err := adapter.ConsumeCode(ctx, "user", "a")
+	if err != nil || !ok {
+		t.Fatalf("expected code to be consumed: %v", err)
+	}
+
+	ok, err = adapter.ConsumeCode(ctx, "user", "a")
+	if err != nil || ok {
+		t.Fatalf("expected code to be single use: %v", err)
+	}
+
+	ok, err = adapter.ConsumeCode(ctx, "user", "unknown")
+	if err != nil || ok {
+		t.Fatalf("expected unknown code not to be consumed: %v", err)
+	}
+
+	count, err := adapter.CountCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error counting codes: %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("expected 2 codes, got %d", count)
+	}
+
+	count, err = adapter.CountCodes(ctx, "other")
+	if err != nil {
+		t.Fatalf("error counting codes: %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("expected the other user's code to remain, got %d", count)
+	}
+}
+
+func TestSQLAdapterConsumeCodeConcurrently(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+
+	err := adapter.ReplaceCodes(ctx, "user", []string{"a"})
+	if err != nil {
+		t.Fatalf("error replacing codes: %v", err)
+	}
+
+	consumed := atomic.Int32{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ok, err := adapter.ConsumeCode(ctx, "user", "a")
+			if err != nil {
+				t.Errorf("error consuming code: %v", err)
+			}
+
+			if ok {
+				consumed.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if consumed.Load() != 1 {
+		t.Fatalf("expected the code to be consumed once, got %d", consumed.Load())
+	}
+}
+
+func TestSQLAdapterReplaceCodes(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+
+	err := adapter.ReplaceCodes(ctx, "user", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("error replacing codes: %v", err)
+	}
+
+	err = adapter.ReplaceCodes(ctx, "user", []string{"c", "d", "e"})
+	if err != nil {
+		t.Fatalf("error replacing codes: %v", err)
+	}
+
+	ok, err := adapter.ConsumeCode(ctx, "user", "a")
+	if err != nil || ok {
+		t.Fatalf("expected old code to be replaced: %v", err)
+	}
+
+	count, err := adapter.CountCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error counting codes: %v", err)
+	}
+
+	if count != 3 {
+		t.Fatalf("expected 3 codes, got %d", count)
+	}
+
+	err = adapter.ReplaceCodes(ctx, "user", nil)
+	if err != nil {
+		t.Fatalf("error replacing codes: %v", err)
+	}
+
+	count, err = adapter.CountCodes(ctx, "user")
+	if err != nil {
+		t.Fatalf("error counting codes: %v", err)
+	}
+
+	if count != 0 {
+		t.Fatalf("expected no codes, got %d", count)
+	}
+}
@@ -25,3 +25,22 @@ type SessionAdapter interface {
 	// DeleteSession invalidates the session with the given sessionID.
 	DeleteSession(ctx context.Context, sessionID string) error
 }
+
+// SessionLister is an optional interface a SessionAdapter can implement to
+// enumerate the sessions of a user. This is required to build pages such as
+// "your active devices" or to sign out every device except the current one.
+type SessionLister interface {
+	// ListSessionsByUserID returns all of the sessions for the given user.
+	ListSessionsByUserID(ctx context.Context, userID string) ([]Session, error)
+}
+
+// SessionRotator is an optional interface a SessionAdapter can implement to
+// move a session to a new session ID in a single operation. Without it the
+// SessionService inserts the session with the new session ID and then deletes
+// the old session.
+type SessionRotator interface {
+	// RotateSession deletes the session with the old session ID and inserts
+	// the new session in its place. It must return ErrSessionNotFound when the
+	// old session does not exist so that a session can only be rotated once.
+	RotateSession(ctx context.Context, oldSessionID string, newSession Session) error
+}
@@ -2,7 +2,12 @@ package auth
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 )
 
@@ -16,70 +21,243 @@ import (
 //   - [Adapters](./adapters)
 //   - [Encrypter](./encrypters)
 //   - [Generator](./generators)
+//   - [Hasher](./hashers)
+//
+// Session IDs are hashed with the Hasher before they are handed to the
+// Adapter. The sessions returned by the SessionService always contain the raw
+// session ID so it can be stored in the cookie.
 type SessionService struct {
-	adapter   SessionAdapter
-	encrypter Encrypter
-	generator Generator
+	adapter          SessionAdapter
+	encrypter        Encrypter
+	generator        Generator
+	hasher           Hasher
+	legacySessionIDs bool
 }
 
 type NewSessionServiceOptions struct {
 	Adapter   SessionAdapter
 	Encrypter Encrypter
 	Generator Generator
+	// Hasher is used to hash session IDs before they are stored. Defaults to
+	// SHA-256.
+	Hasher Hasher
+	// LegacySessionIDs enables compatibility with stores that contain raw
+	// session IDs. When a session cannot be found by its hashed ID, it is looked
+	// up by its raw ID and moved to the hashed ID. Session IDs that look like
+	// Hasher output are never looked up by their raw ID so that rows copied
+	// from a leaked sessions table cannot be used as session IDs, which means
+	// legacy sessions with IDs of that shape have to sign in again.
+	//
+	// This mode must only be enabled temporarily while the existing sessions
+	// are migrated, disable it once the raw session IDs have expired.
+	LegacySessionIDs bool
 }
 
-// NewSessionService returns a new instance of Auth.
+// NewSessionService returns a new instance of Auth. A warning is logged when
+// the Generator reports less than MinEntropyBits of entropy because
+// CreateSession will refuse to use it.
 func NewSessionService(options NewSessionServiceOptions) *SessionService {
+	if err := checkEntropy(options.Generator); err != nil {
+		slog.Warn("sessions cannot be created with this generator", "error", err)
+	}
+
+	hasher := options.Hasher
+	if hasher == nil {
+		hasher = NewSha256Hasher()
+	}
+
 	return &SessionService{
-		adapter:   options.Adapter,
-		encrypter: options.Encrypter,
-		generator: options.Generator,
+		adapter:          options.Adapter,
+		encrypter:        options.Encrypter,
+		generator:        options.Generator,
+		hasher:           hasher,
+		legacySessionIDs: options.LegacySessionIDs,
 	}
 }
 
 // 4. Return the session and cookie
 func (a *SessionService) CreateSession(ctx context.Context, newSession Session) (Session, error) {
+	err := checkEntropy(a.generator)
+	if err != nil {
+		return nil, err
+	}
+
 	sessionID, err := a.generator.Generate()
 	if err != nil {
-		return nil, fmt.Errorf("error generating session id: %s", err.Error())
+		return nil, fmt.Errorf("error generating session id: %w", err)
 	}
 
 	insertedSession := newSession.Copy()
-	insertedSession.SetSessionID(sessionID)
+	insertedSession.SetSessionID(a.hasher.Hash(sessionID))
+
+	if authenticatedSession, ok := insertedSession.(AuthenticatedSession); ok && authenticatedSession.GetAuthenticationLevel() == AuthenticationLevelNone {
+		authenticatedSession.SetAuthenticationLevel(DefaultAuthenticationLevel)
+	}
 
 	err = a.adapter.InsertSession(ctx, insertedSession)
 	if err != nil {
-		return nil, fmt.Errorf("error inserting session: %s", err.Error())
+		return nil, fmt.Errorf("error inserting session: %w", err)
 	}
 
-	return insertedSession, nil
+	return a.withSessionID(insertedSession, sessionID), nil
 }
 
 func (a *SessionService) GetSession(ctx context.Context, sessionID string) (Session, error) {
-	session, err := a.adapter.GetSession(ctx, sessionID)
+	hashedSessionID := a.hasher.Hash(sessionID)
+
+	session, err := a.adapter.GetSession(ctx, hashedSessionID)
+	if errors.Is(err, ErrSessionNotFound) && a.legacySessionIDs {
+		session, err = a.getLegacySession(ctx, sessionID, hashedSessionID)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting session: %s", err.Error())
+		return nil, fmt.Errorf("error getting session: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(session.GetSessionID()), []byte(hashedSessionID)) != 1 {
+		return nil, fmt.Errorf("error getting session: %w", ErrSessionNotFound)
 	}
 
 	if session.GetExpiresAt().Before(time.Now()) {
-		return nil, fmt.Errorf("session is expired: %s", session.GetExpiresAt().Format(time.RFC3339))
+		return nil, fmt.Errorf("%w: %s", ErrSessionExpired, session.GetExpiresAt().Format(time.RFC3339))
 	}
 
-	return session, nil
+	return a.withSessionID(session, sessionID), nil
 }
 
 func (a *SessionService) UpdateSession(ctx context.Context, session Session) error {
-	return a.adapter.UpdateSession(ctx, session)
+	return a.adapter.UpdateSession(ctx, a.withSessionID(session, a.hasher.Hash(session.GetSessionID())))
 }
 
 func (a *SessionService) DeleteSession(ctx context.Context, sessionID string) error {
-	return a.adapter.DeleteSession(ctx, sessionID)
+	hashedSessionID := a.hasher.Hash(sessionID)
+
+	err := a.adapter.DeleteSession(ctx, hashedSessionID)
+	if err != nil {
+		return err
+	}
+
+	if a.legacySessionIDs && !looksHashed(sessionID, hashedSessionID) {
+		return a.adapter.DeleteSession(ctx, sessionID)
+	}
+
+	return nil
 }
 
 func (a *SessionService) DeleteSessionsByUserID(ctx context.Context, userID string) error {
 	return a.adapter.DeleteSessionsByUserID(ctx, userID)
 }
 
+// UpgradeSession raises the authentication level of the session to the given
+// level and records the factor that was verified. The level is never lowered.
+// The session is moved to a new session ID so that an ID captured before the
+// upgrade cannot be used at the new level. The session must implement
+// AuthenticatedSession.
+func (a *SessionService) UpgradeSession(ctx context.Context, session Session, level AuthenticationLevel, factor string) (Session, error) {
+	upgradedSession, ok := session.Copy().(AuthenticatedSession)
+	if !ok {
+		return nil, fmt.Errorf("%w: session does not implement AuthenticatedSession", ErrNotSupported)
+	}
+
+	if level > upgradedSession.GetAuthenticationLevel() {
+		upgradedSession.SetAuthenticationLevel(level)
+	}
+
+	factors := upgradedSession.GetAuthenticationFactors()
+	if factor != "" && !slices.Contains(factors, factor) {
+		upgradedSession.SetAuthenticationFactors(append(slices.Clone(factors), factor))
+	}
+
+	return a.rotateSession(ctx, session.GetSessionID(), upgradedSession)
+}
+
+// RotateSession moves the session to a new session ID and returns it with the
+// new session ID. Everything else about the session is kept. Rotate the
+// session whenever the user's privileges change, such as when they sign in,
+// to prevent session fixation. The session is moved in a single operation
+// when the adapter implements SessionRotator. ErrSessionNotFound is returned
+// when the session has already been deleted.
+func (a *SessionService) RotateSession(ctx context.Context, session Session) (Session, error) {
+	return a.rotateSession(ctx, session.GetSessionID(), session)
+}
+
+// ListUserSessions returns copies of all of the active sessions for the given
+// user. The adapter must implement SessionLister. Expired sessions are left out
+// and deleted. The returned sessions contain the hashed session IDs because the
+// raw session IDs are never stored, use HashSessionID to find the current
+// session in the list. The hashed session IDs cannot be used with GetSession or
+// DeleteSession.
+func (a *SessionService) ListUserSessions(ctx context.Context, userID string) ([]Session, error) {
+	lister, ok := a.adapter.(SessionLister)
+	if !ok {
+		return nil, fmt.Errorf("%w: adapter does not implement SessionLister", ErrNotSupported)
+	}
+
+	sessions, err := lister.ListSessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing sessions: %w", err)
+	}
+
+	now := time.Now()
+	activeSessions := make([]Session, 0, len(sessions))
+
+	for _, session := range sessions {
+		if !session.GetExpiresAt().Before(now) {
+			activeSessions = append(activeSessions, session.Copy())
+
+			continue
+		}
+
+		err = a.adapter.DeleteSession(ctx, session.GetSessionID())
+		if err != nil {
+			return nil, fmt.Errorf("error deleting expired session: %w", err)
+		}
+	}
+
+	return activeSessions, nil
+}
+
+// DeleteOtherSessions deletes all of the sessions for the given user except for
+// the session with the given session ID. This can be used to sign out every
+// other device. The adapter must implement SessionLister.
+func (a *SessionService) DeleteOtherSessions(ctx context.Context, userID string, keepSessionID string) error {
+	sessions, err := a.ListUserSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	hashedKeepSessionID := a.hasher.Hash(keepSessionID)
+
+	for _, session := range sessions {
+		storedSessionID := session.GetSessionID()
+		if storedSessionID == hashedKeepSessionID || (a.legacySessionIDs && storedSessionID == keepSessionID && !looksHashed(keepSessionID, hashedKeepSessionID)) {
+			continue
+		}
+
+		err = a.adapter.DeleteSession(ctx, storedSessionID)
+		if err != nil {
+			return fmt.Errorf("error deleting session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// HashSessionID returns the session ID as it is stored by the adapter.
+func (a *SessionService) HashSessionID(sessionID string) string {
+	return a.hasher.Hash(sessionID)
+}
+
+// DeleteExpiredSessions deletes all expired sessions from the adapter. The
+// adapter must implement Sweeper.
+func (a *SessionService) DeleteExpiredSessions(ctx context.Context) error {
+	sweeper, ok := a.adapter.(Sweeper)
+	if !ok {
+		return fmt.Errorf("%w: adapter does not implement Sweeper", ErrNotSupported)
+	}
+
+	return sweeper.DeleteExpiredSessions(ctx)
+}
+
 func (a *SessionService) EncryptSessionID(sessionID string) (string, error) {
 	return a.encrypter.Encrypt(sessionID)
 }
@@ -87,3 +265,181 @@ func (a *SessionService) EncryptSessionID(sessionID string) (string, error) {
 func (a *SessionService) DecryptSessionID(encryptedSessionID string) (string, error) {
 	return a.encrypter.Decrypt(encryptedSessionID)
 }
+
+// EncryptSessionIDWithAAD encrypts the session ID bound to the additional data.
+// The session ID is encrypted without it when the Encrypter does not implement
+// AADEncrypter.
+func (a *SessionService) EncryptSessionIDWithAAD(sessionID string, additionalData string) (string, error) {
+	encrypter, ok := a.encrypter.(AADEncrypter)
+	if !ok {
+		return a.encrypter.Encrypt(sessionID)
+	}
+
+	return encrypter.EncryptWithAAD(sessionID, additionalData)
+}
+
+// DecryptSessionIDWithAAD decrypts a session ID encrypted by
+// EncryptSessionIDWithAAD with the same additional data.
+func (a *SessionService) DecryptSessionIDWithAAD(encryptedSessionID string, additionalData string) (string, error) {
+	encrypter, ok := a.encrypter.(AADEncrypter)
+	if !ok {
+		return a.encrypter.Decrypt(encryptedSessionID)
+	}
+
+	return encrypter.DecryptWithAAD(encryptedSessionID, additionalData)
+}
+
+// SessionIDNeedsRotation reports whether the encrypted session ID was
+// encrypted with a key that is no longer the active key. It is always false
+// when the Encrypter does not implement KeyRotator.
+func (a *SessionService) SessionIDNeedsRotation(encryptedSessionID string) bool {
+	rotator, ok := a.encrypter.(KeyRotator)
+	if !ok {
+		return false
+	}
+
+	return rotator.NeedsRotation(encryptedSessionID)
+}
+
+// checkEntropy returns an error when the generator reports less than
+// MinEntropyBits of entropy. Generators that do not report their entropy are
+// trusted.
+func checkEntropy(generator Generator) error {
+	entropyGenerator, ok := generator.(EntropyGenerator)
+	if !ok {
+		return nil
+	}
+
+	if bits := entropyGenerator.EntropyBits(); bits < MinEntropyBits {
+		return fmt.Errorf("%w: %d bits, at least %d bits are required", ErrInsufficientEntropy, bits, MinEntropyBits)
+	}
+
+	return nil
+}
+
+// getLegacySession looks up a session that was stored with its raw session ID
+// and moves it to its hashed session ID.
+func (a *SessionService) getLegacySession(ctx context.Context, sessionID string, hashedSessionID string) (Session, error) {
+	if looksHashed(sessionID, hashedSessionID) {
+		return nil, ErrSessionNotFound
+	}
+
+	legacySession, err := a.adapter.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(legacySession.GetSessionID()), []byte(sessionID)) != 1 {
+		return nil, ErrSessionNotFound
+	}
+
+	session := a.withSessionID(legacySession, hashedSessionID)
+
+	err = a.adapter.InsertSession(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("error migrating legacy session: %w", err)
+	}
+
+	err = a.adapter.DeleteSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error migrating legacy session: %w", err)
+	}
+
+	return session, nil
+}
+
+// looksHashed reports whether the session ID has the shape of the Hasher
+// output, the same length and, when the output is hex, only hex characters.
+// Hashed session IDs are stored as is, so accepting them as raw session IDs
+// would make a leaked sessions table usable again.
+func looksHashed(sessionID string, hashedSessionID string) bool {
+	if len(sessionID) != len(hashedSessionID) {
+		return false
+	}
+
+	if !isHex(hashedSessionID) {
+		return true
+	}
+
+	return isHex(sessionID)
+}
+
+func isHex(value string) bool {
+	_, err := hex.DecodeString(value)
+
+	return err == nil
+}
+
+// rotateSession stores the session under a new session ID and deletes the
+// session with the old session ID.
+func (a *SessionService) rotateSession(ctx context.Context, oldSessionID string, session Session) (Session, error) {
+	err := checkEntropy(a.generator)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := a.generator.Generate()
+	if err != nil {
+		return nil, fmt.Errorf("error generating session id: %w", err)
+	}
+
+	rotatedSession := a.withSessionID(session, a.hasher.Hash(sessionID))
+
+	if rotator, ok := a.adapter.(SessionRotator); ok {
+		err = rotator.RotateSession(ctx, a.hasher.Hash(oldSessionID), rotatedSession)
+		if err != nil {
+			return nil, fmt.Errorf("error rotating session: %w", err)
+		}
+
+		if a.legacySessionIDs && !looksHashed(oldSessionID, a.hasher.Hash(oldSessionID)) {
+			err = a.adapter.DeleteSession(ctx, oldSessionID)
+			if err != nil {
+				return nil, fmt.Errorf("error deleting session: %w", err)
+			}
+		}
+
+		return a.withSessionID(rotatedSession, sessionID), nil
+	}
+
+	// Without a SessionRotator the session is moved in two steps. Make sure the
+	// old session still exists so that a session deleted by another request,
+	// such as a sign out, is not brought back under the new session ID.
+	_, err = a.getStoredSession(ctx, oldSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error rotating session: %w", err)
+	}
+
+	err = a.adapter.InsertSession(ctx, rotatedSession)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting session: %w", err)
+	}
+
+	err = a.DeleteSession(ctx, oldSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting session: %w", err)
+	}
+
+	return a.withSessionID(rotatedSession, sessionID), nil
+}
+
+// getStoredSession returns the stored session for the raw session ID without
+// migrating legacy sessions.
+func (a *SessionService) getStoredSession(ctx context.Context, sessionID string) (Session, error) {
+	hashedSessionID := a.hasher.Hash(sessionID)
+
+	session, err := a.adapter.GetSession(ctx, hashedSessionID)
+	if errors.Is(err, ErrSessionNotFound) && a.legacySessionIDs && !looksHashed(sessionID, hashedSessionID) {
+		return a.adapter.GetSession(ctx, sessionID)
+	}
+
+	return session, err
+}
+
+// withSessionID returns a copy of the session with the given session ID. The
+// session is copied so that sessions held by the adapter are never modified.
+func (a *SessionService) withSessionID(session Session, sessionID string) Session {
+	sessionCopy := session.Copy()
+	sessionCopy.SetSessionID(sessionID)
+
+	return sessionCopy
+}
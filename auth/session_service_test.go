@@ -0,0 +1,373 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	adaptors "github.com/lukeshay/g/auth/adapters"
+	"github.com/lukeshay/g/auth/generators"
+)
+
+func newTestService(t *testing.T, adapter auth.SessionAdapter, legacySessionIDs bool) *auth.SessionService {
+	t.Helper()
+
+	return auth.NewSessionService(auth.NewSessionServiceOptions{
+		Adapter:          adapter,
+		Generator:        generators.NewBase32LowerGenerator(20),
+		LegacySessionIDs: legacySessionIDs,
+	})
+}
+
+func newTestSession(userID string) *adaptors.Session {
+	return &adaptors.Session{
+		UserID:       userID,
+		ExpiresAt:    time.Now().Add(time.Hour),
+		RefreshUntil: time.Now().Add(time.Hour),
+	}
+}
+
+func TestSessionServiceHashesSessionIDs(t *testing.T) {
+	ctx := context.Background()
+	adapter := adaptors.NewInMemoryAdapter()
+	service := newTestService(t, adapter, false)
+
+	session, err := service.CreateSession(ctx, newTestSession("user"))
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+
+	sessionID := session.GetSessionID()
+	hashedSessionID := auth.NewSha256Hasher().Hash(sessionID)
+
+	if service.HashSessionID(sessionID) != hashedSessionID {
+		t.Fatalf("expected the SHA-256 hasher by default")
+	}
+
+	_, err = adapter.GetSession(ctx, sessionID)
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected the raw session ID not to be stored, got %v", err)
+	}
+
+	stored, err := adapter.GetSession(ctx, hashedSessionID)
+	if err != nil {
+		t.Fatalf("expected the hashed session ID to be stored, got %v", err)
+	}
+
+	if stored.GetUserID() != "user" {
+		t.Errorf("unexpected stored session: %+v", stored)
+	}
+
+	got, err := service.GetSession(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("error getting session: %v", err)
+	}
+
+	if got.GetSessionID() != sessionID {
+		t.Errorf("expected the raw session ID to be returned, got %q", got.GetSessionID())
+	}
+
+	_, err = service.GetSession(ctx, hashedSessionID)
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected the hashed session ID to be rejected, got %v", err)
+	}
+
+	err = service.DeleteSession(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("error deleting session: %v", err)
+	}
+
+	_, err = adapter.GetSession(ctx, hashedSessionID)
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected the session to be deleted, got %v", err)
+	}
+}
+
+func TestSessionServiceLegacySessionIDs(t *testing.T) {
+	ctx := context.Background()
+	adapter := adaptors.NewInMemoryAdapter()
+	legacySession := newTestSession("user")
+	legacySession.SessionID = "legacy-session-id"
+
+	err := adapter.InsertSession(ctx, legacySession)
+	if err != nil {
+		t.Fatalf("error inserting session: %v", err)
+	}
+
+	_, err = newTestService(t, adapter, false).GetSession(ctx, "legacy-session-id")
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected raw session IDs to be ignored without LegacySessionIDs, got %v", err)
+	}
+
+	service := newTestService(t, adapter, true)
+
+	session, err := service.GetSession(ctx, "legacy-session-id")
+	if err != nil {
+		t.Fatalf("error getting legacy session: %v", err)
+	}
+
+	if session.GetSessionID() != "legacy-session-id" {
+		t.Errorf("expected the raw session ID to be returned, got %q", session.GetSessionID())
+	}
+
+	_, err = adapter.GetSession(ctx, "legacy-session-id")
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected the raw session ID to be removed, got %v", err)
+	}
+
+	_, err = adapter.GetSession(ctx, service.HashSessionID("legacy-session-id"))
+	if err != nil {
+		t.Fatalf("expected the session to be moved to its hashed ID, got %v", err)
+	}
+}
+
+func TestSessionServiceLegacySessionIDsRejectsHashedIDs(t *testing.T) {
+	ctx := context.Background()
+	adapter := adaptors.NewInMemoryAdapter()
+	service := newTestService(t, adapter, true)
+
+	session, err := service.CreateSession(ctx, newTestSession("user"))
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+
+	// A hashed session ID copied from a leaked sessions table must not be
+	// accepted as a raw session ID.
+	leakedSessionID := service.HashSessionID(session.GetSessionID())
+
+	_, err = service.GetSession(ctx, leakedSessionID)
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	err = service.DeleteSession(ctx, leakedSessionID)
+	if err != nil {
+		t.Fatalf("error deleting session: %v", err)
+	}
+
+	_, err = service.GetSession(ctx, session.GetSessionID())
+	if err != nil {
+		t.Fatalf("expected the session to remain, got %v", err)
+	}
+}
+
+func TestSessionServiceInsufficientEntropy(t *testing.T) {
+	logs := &bytes.Buffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(logs, nil)))
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+	})
+
+	service := auth.NewSessionService(auth.NewSessionServiceOptions{
+		Adapter:   adaptors.NewInMemoryAdapter(),
+		Generator: generators.NewNumericGenerator(6),
+	})
+
+	if !strings.Contains(logs.String(), "sessions cannot be created with this generator") {
+		t.Errorf("expected an entropy warning, got %q", logs.String())
+	}
+
+	_, err := service.CreateSession(context.Background(), newTestSession("user"))
+	if !errors.Is(err, auth.ErrInsufficientEntropy) {
+		t.Fatalf("expected ErrInsufficientEntropy, got %v", err)
+	}
+}
+
+func TestSessionServiceListUserSessions(t *testing.T) {
+	ctx := context.Background()
+	adapter := adaptors.NewInMemoryAdapter()
+	service := newTestService(t, adapter, false)
+
+	session, err := service.CreateSession(ctx, newTestSession("user"))
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+
+	_, err = service.CreateSession(ctx, newTestSession("other"))
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+
+	expiredSession := newTestSession("user")
+	expiredSession.ExpiresAt = time.Now().Add(-time.Minute)
+
+	expired, err := service.CreateSession(ctx, expiredSession)
+	if err != nil {
+		t.Fatalf("error creating session: %v", err)
+	}
+
+	sessions, err := service.ListUserSessions(ctx, "user")
+	if err != nil {
+		t.Fatalf("error listing sessions: %v", err)
+	}
+
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 active session, got %d", len(sessions))
+	}
+
+	hashedSessionID := service.HashSessionID(session.GetSessionID())
+	if sessions[0].GetSessionID() != hashedSessionID {
+		t.Errorf("expected the hashed session ID, got %q", sessions[0].GetSessionID())
+	}
+
+	_, err = adapter.GetSession(ctx, service.HashSessionID(expired.GetSessionID()))
+	if !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("expected the expired session to be deleted, got %v", err)
+	}
+
+	listed := sessions[0].(*adaptors.Session)
+	listed.UserID = "changed"
+	listed.ExpiresAt = time.Now().Add(-time.Minute)
+
+	stored, err := adapter.GetSession(ctx, hashedSessionID)
+	if err != nil {
+		t.Fatalf("error getting session: %v", err)
+	}
+
+	if stored.GetUserID() != "user" || stored.GetExpiresAt().Before(time.Now()) {
+		t.Errorf("expected changes to the listed session not to change the stored session, got %+v", stored)
+	}
+}
+
+// basicAdapter hides the optional interfaces of the wrapped adapter so that
+// the service falls back to its own implementations. The errors are returned
+// in place of calling the wrapped adapter.
+type basicAdapter struct {
+	auth.SessionAdapter
+	insertErr error
+	deleteErr error
+}
+
+func (a *basicAdapter) InsertSession(ctx context.Context, session auth.Session) error {
+	if a.insertErr != nil {
+		return a.insertErr
+	}
+
+	return a.SessionAdapter.InsertSession(ctx, session)
+}
+
+func (a *basicAdapter) DeleteSession(ctx context.Context, sessionID string) error {
+	if a.deleteErr != nil {
+		return a.deleteErr
+	}
+
+	return a.SessionAdapter.DeleteSession(ctx, sessionID)
+}
+
+func TestSessionServiceRotateSession(t *testing.T) {
+	tests := map[string]func(auth.SessionAdapter) auth.SessionAdapter{
+		"SessionRotator": func(adapter auth.SessionAdapter) auth.SessionAdapter {
+			return adapter
+		},
+		"Fallback": func(adapter auth.SessionAdapter) auth.SessionAdapter {
+			return &basicAdapter{SessionAdapter: adapter}
+		},
+	}
+
+	for name, wrap := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			adapter := adaptors.NewInMemoryAdapter()
+			service := newTestService(t, wrap(adapter), false)
+
+			session, err := service.CreateSession(ctx, newTestSession("user"))
+			if err != nil {
+				t.Fatalf("error creating session: %v", err)
+			}
+
+			rotated, err := service.RotateSession(ctx, session)
+			if err != nil {
+				t.Fatalf("error rotating session: %v", err)
+			}
+
+			if rotated.GetSessionID() == session.GetSessionID() {
+				t.Fatalf("expected a new session ID")
+			}
+
+			if rotated.GetUserID() != "user" || !rotated.GetExpiresAt().Equal(session.GetExpiresAt()) {
+				t.Errorf("expected the rest of the session to be kept, got %+v", rotated)
+			}
+
+			_, err = service.GetSession(ctx, session.GetSessionID())
+			if !errors.Is(err, auth.ErrSessionNotFound) {
+				t.Fatalf("expected the old session ID to be removed, got %v", err)
+			}
+
+			_, err = service.GetSession(ctx, rotated.GetSessionID())
+			if err != nil {
+				t.Fatalf("error getting rotated session: %v", err)
+			}
+
+			// Rotating the old session again, such as after it was signed out
+			// by another request, must not bring it back.
+			_, err = service.RotateSession(ctx, session)
+			if !errors.Is(err, auth.ErrSessionNotFound) {
+				t.Fatalf("expected ErrSessionNotFound, got %v", err)
+			}
+
+			sessions, err := adapter.(auth.SessionLister).ListSessionsByUserID(ctx, "user")
+			if err != nil {
+				t.Fatalf("error listing sessions: %v", err)
+			}
+
+			if len(sessions) != 1 {
+				t.Fatalf("expected 1 stored session, got %d", len(sessions))
+			}
+		})
+	}
+}
+
+func TestSessionServiceRotateSessionFallbackErrors(t *testing.T) {
+	ctx := context.Background()
+	errAdapter := errors.New("adapter error")
+
+	tests := map[string]struct {
+		adapter     *basicAdapter
+		storedAfter int
+	}{
+		// The old session is only deleted after the new one is stored, so a
+		// failed insert leaves the user signed in with the old session.
+		"insert": {adapter: &basicAdapter{insertErr: errAdapter}, storedAfter: 1},
+		// The fallback is not atomic, a failed delete leaves both sessions.
+		"delete": {adapter: &basicAdapter{deleteErr: errAdapter}, storedAfter: 2},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			adapter := adaptors.NewInMemoryAdapter()
+			test.adapter.SessionAdapter = adapter
+
+			session, err := newTestService(t, adapter, false).CreateSession(ctx, newTestSession("user"))
+			if err != nil {
+				t.Fatalf("error creating session: %v", err)
+			}
+
+			_, err = newTestService(t, test.adapter, false).RotateSession(ctx, session)
+			if !errors.Is(err, errAdapter) {
+				t.Fatalf("expected the adapter error, got %v", err)
+			}
+
+			service := newTestService(t, adapter, false)
+
+			_, err = service.GetSession(ctx, session.GetSessionID())
+			if err != nil {
+				t.Fatalf("expected the old session to remain, got %v", err)
+			}
+
+			sessions, err := adapter.(auth.SessionLister).ListSessionsByUserID(ctx, "user")
+			if err != nil {
+				t.Fatalf("error listing sessions: %v", err)
+			}
+
+			if len(sessions) != test.storedAfter {
+				t.Fatalf("expected %d stored sessions, got %d", test.storedAfter, len(sessions))
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Sweeper is an optional interface a SessionAdapter can implement to delete
+// sessions that have expired. Expired sessions are already rejected by the
+// SessionService, sweeping them just keeps the datastore from growing forever.
+type Sweeper interface {
+	// DeleteExpiredSessions deletes all sessions that expired before now.
+	DeleteExpiredSessions(ctx context.Context) error
+}
+
+// Janitor periodically calls a Sweeper in a background goroutine. The
+// SessionService implements Sweeper, so it can be passed directly.
+type Janitor struct {
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+type StartJanitorOptions struct {
+	// Sweeper is called every Interval.
+	Sweeper Sweeper
+	// Interval is how often the Sweeper is called. Defaults to one minute.
+	Interval time.Duration
+	// OnError is called with the errors returned by the Sweeper. Errors are
+	// ignored when it is nil.
+	OnError func(error)
+}
+
+// StartJanitor starts a new Janitor. The Janitor runs until Stop is called or
+// the given context is cancelled.
+func StartJanitor(ctx context.Context, options StartJanitorOptions) *Janitor {
+	if options.Interval <= 0 {
+		options.Interval = time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	j := &Janitor{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go j.run(ctx, options)
+
+	return j
+}
+
+// Stop stops the Janitor and waits for the current sweep to finish.
+func (j *Janitor) Stop() {
+	j.stopOnce.Do(j.cancel)
+
+	<-j.done
+}
+
+func (j *Janitor) run(ctx context.Context, options StartJanitorOptions) {
+	defer close(j.done)
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := options.Sweeper.DeleteExpiredSessions(ctx)
+			if err != nil && options.OnError != nil {
+				options.OnError(err)
+			}
+		}
+	}
+}
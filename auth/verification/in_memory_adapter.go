@@ -0,0 +1,55 @@
+package verification
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// InMemoryAdapter is an in-memory implementation of the Adapter interface.
+type InMemoryAdapter struct {
+	mu     sync.Mutex
+	tokens map[string]VerificationToken
+}
+
+// NewInMemoryAdapter returns a new instance of InMemoryAdapter.
+func NewInMemoryAdapter() Adapter {
+	return &InMemoryAdapter{
+		tokens: map[string]VerificationToken{},
+	}
+}
+
+func (a *InMemoryAdapter) ReplaceToken(ctx context.Context, token VerificationToken, throttledAfter time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, existingToken := range a.tokens {
+		if existingToken.UserID == token.UserID && existingToken.CreatedAt.After(throttledAfter) {
+			return ErrResendThrottled
+		}
+	}
+
+	for tokenHash, existingToken := range a.tokens {
+		if existingToken.UserID == token.UserID {
+			delete(a.tokens, tokenHash)
+		}
+	}
+
+	a.tokens[token.TokenHash] = token
+
+	return nil
+}
+
+func (a *InMemoryAdapter) ConsumeToken(ctx context.Context, tokenHash string) (VerificationToken, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	token, found := a.tokens[tokenHash]
+	if !found {
+		return VerificationToken{}, ErrTokenNotFound
+	}
+
+	delete(a.tokens, tokenHash)
+
+	return token, nil
+}
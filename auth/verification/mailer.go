@@ -0,0 +1,85 @@
+package verification
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message is an email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails. Implement it with the email provider of your choice.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// InMemoryMailer is a Mailer that keeps the messages in memory instead of
+// sending them. It is meant for tests.
+type InMemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewInMemoryMailer returns a new instance of InMemoryMailer.
+func NewInMemoryMailer() *InMemoryMailer {
+	return &InMemoryMailer{}
+}
+
+func (m *InMemoryMailer) Send(ctx context.Context, message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+
+	return nil
+}
+
+// Messages returns the messages that have been sent, oldest first.
+func (m *InMemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// FileMailer is a Mailer that writes each message to a file in a directory
+// instead of sending it. It is meant for local development.
+type FileMailer struct {
+	dir string
+}
+
+// NewFileMailer returns a new instance of FileMailer. The directory is created
+// if it does not exist.
+func NewFileMailer(dir string) (*FileMailer, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("error creating mail directory: %w", err)
+	}
+
+	return &FileMailer{
+		dir: dir,
+	}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	file, err := os.CreateTemp(m.dir, fmt.Sprintf("%d-*.eml", time.Now().UnixNano()))
+	if err != nil {
+		return fmt.Errorf("error creating mail file: %w", err)
+	}
+
+	_, err = fmt.Fprintf(file, "To: %s\r\nSubject: %s\r\n\r\n%s\r\n", message.To, message.Subject, message.Body)
+	if err != nil {
+		file.Close()
+
+		return fmt.Errorf("error writing mail file: %w", err)
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,126 @@
+package sqladapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/lukeshay/g/auth/internal/sqlmigrate"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+const (
+	// MigrationsTableName is the table Migrate uses to track which of the
+	// verification token migrations have been applied.
+	MigrationsTableName = "auth_verification_migrations"
+	// MigrationLocksTableName is the table Migrate uses to make sure only one
+	// process runs the verification token migrations at a time.
+	MigrationLocksTableName = "auth_verification_migration_locks"
+)
+
+// Migrations contains the versioned schema migrations for the
+// verification_tokens table. Migrations are never changed once released, new
+// versions are appended instead.
+var Migrations = migrate.NewMigrations()
+
+// verificationTokenV1 is a snapshot of the verification_tokens table when it
+// was first created.
+type verificationTokenV1 struct {
+	bun.BaseModel `bun:"table:verification_tokens"`
+
+	TokenHash string    `bun:",pk"`
+	UserID    string    `bun:",notnull"`
+	Email     string    `bun:",notnull"`
+	CreatedAt time.Time `bun:",notnull"`
+	ExpiresAt time.Time `bun:",notnull"`
+}
+
+func init() {
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000001",
+		Comment: "create_verification_tokens_table",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateTable().Model((*verificationTokenV1)(nil)).IfNotExists().Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropTable().Model((*verificationTokenV1)(nil)).IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000002",
+		Comment: "create_verification_tokens_user_id_index",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewCreateIndex().
+				Model((*verificationTokenV1)(nil)).
+				Index("verification_tokens_user_id_idx").
+				Column("user_id").
+				IfNotExists().
+				Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropIndex().Index("verification_tokens_user_id_idx").IfExists().Exec(ctx)
+
+			return err
+		},
+	})
+
+	Migrations.Add(migrate.Migration{
+		Name:    "00000000000003",
+		Comment: "make_verification_tokens_user_id_index_unique",
+		Up: func(ctx context.Context, db *bun.DB) error {
+			// Only the newest token of each user works, so the tokens of users
+			// with more than one are deleted and they can request a new one. The
+			// subquery is nested so that MySQL allows it.
+			_, err := db.NewDelete().
+				Model((*verificationTokenV1)(nil)).
+				Where("user_id IN (SELECT user_id FROM (SELECT user_id FROM verification_tokens GROUP BY user_id HAVING COUNT(*) > 1) AS duplicates)").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.NewDropIndex().Index("verification_tokens_user_id_idx").IfExists().Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.NewCreateIndex().
+				Model((*verificationTokenV1)(nil)).
+				Unique().
+				Index("verification_tokens_user_id_key").
+				Column("user_id").
+				IfNotExists().
+				Exec(ctx)
+
+			return err
+		},
+		Down: func(ctx context.Context, db *bun.DB) error {
+			_, err := db.NewDropIndex().Index("verification_tokens_user_id_key").IfExists().Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.NewCreateIndex().
+				Model((*verificationTokenV1)(nil)).
+				Index("verification_tokens_user_id_idx").
+				Column("user_id").
+				IfNotExists().
+				Exec(ctx)
+
+			return err
+		},
+	})
+}
+
+// Migrate applies all of the verification token migrations that have not been
+// applied to the database yet.
+func Migrate(ctx context.Context, db *bun.DB) error {
+	return sqlmigrate.Run(ctx, db, Migrations, MigrationsTableName, MigrationLocksTableName)
+}
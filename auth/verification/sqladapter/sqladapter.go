@@ -0,0 +1,125 @@
+package sqladapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lukeshay/g/auth/verification"
+	"github.com/uptrace/bun"
+)
+
+// VerificationToken is the model for the verification_tokens table created by
+// [Migrations].
+type VerificationToken struct {
+	bun.BaseModel `bun:"table:verification_tokens"`
+
+	TokenHash string    `bun:",pk"`
+	UserID    string    `bun:",notnull"`
+	Email     string    `bun:",notnull"`
+	CreatedAt time.Time `bun:",notnull"`
+	ExpiresAt time.Time `bun:",notnull"`
+}
+
+// SQLAdapter is an implementation of the verification.Adapter interface that
+// stores verification tokens in any database supported by bun.
+type SQLAdapter struct {
+	db bun.IDB
+}
+
+type NewOptions struct {
+	// DB is the database or transaction the verification tokens are stored in.
+	DB bun.IDB
+}
+
+// New returns a new instance of SQLAdapter.
+func New(options NewOptions) verification.Adapter {
+	return &SQLAdapter{
+		db: options.DB,
+	}
+}
+
+// ReplaceToken deletes the user's tokens that are old enough to be replaced
+// and inserts the token. The unique user_id index created by Migrations makes
+// the insert fail when the user still has a token, so concurrent calls cannot
+// both insert one.
+func (a *SQLAdapter) ReplaceToken(ctx context.Context, token verification.VerificationToken, throttledAfter time.Time) error {
+	_, err := a.db.NewDelete().
+		Model((*VerificationToken)(nil)).
+		Where("user_id = ?", token.UserID).
+		Where("created_at <= ?", throttledAfter).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := a.db.NewInsert().Model(&VerificationToken{
+		TokenHash: token.TokenHash,
+		UserID:    token.UserID,
+		Email:     token.Email,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	}).Ignore().Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if inserted == 0 {
+		return verification.ErrResendThrottled
+	}
+
+	return nil
+}
+
+// ConsumeToken selects and deletes the token in a transaction. The token is
+// only returned when this call deleted it, so concurrent calls cannot both
+// consume it.
+func (a *SQLAdapter) ConsumeToken(ctx context.Context, tokenHash string) (verification.VerificationToken, error) {
+	model := &VerificationToken{}
+
+	err := a.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		err := tx.NewSelect().Model(model).Where("token_hash = ?", tokenHash).Scan(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return verification.ErrTokenNotFound
+		} else if err != nil {
+			return err
+		}
+
+		result, err := tx.NewDelete().Model((*VerificationToken)(nil)).Where("token_hash = ?", tokenHash).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if deleted == 0 {
+			return verification.ErrTokenNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return verification.VerificationToken{}, err
+	}
+
+	return toVerificationToken(model), nil
+}
+
+func toVerificationToken(model *VerificationToken) verification.VerificationToken {
+	return verification.VerificationToken{
+		TokenHash: model.TokenHash,
+		UserID:    model.UserID,
+		Email:     model.Email,
+		CreatedAt: model.CreatedAt,
+		ExpiresAt: model.ExpiresAt,
+	}
+}
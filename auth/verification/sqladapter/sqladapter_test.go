@@ -0,0 +1,145 @@
+package sqladapter_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth/verification"
+	"github.com/lukeshay/g/auth/verification/sqladapter"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+)
+
+func newTestAdapter(t *testing.T) (verification.Adapter, *bun.DB) {
+	t.Helper()
+
+	sqldb, err := sql.Open(sqliteshim.ShimName, fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	err = sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("error migrating database: %v", err)
+	}
+
+	return sqladapter.New(sqladapter.NewOptions{DB: db}), db
+}
+
+func newTestToken(tokenHash string, userID string, createdAt time.Time) verification.VerificationToken {
+	return verification.VerificationToken{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		Email:     userID + "@example.com",
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(time.Hour),
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	_, db := newTestAdapter(t)
+
+	err := sqladapter.Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("expected migrations to be idempotent, got %v", err)
+	}
+}
+
+func TestSQLAdapterConsumeToken(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	err := adapter.ReplaceToken(ctx, newTestToken("a", "user", now), now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("error replacing token: %v", err)
+	}
+
+	token, err := adapter.ConsumeToken(ctx, "a")
+	if err != nil {
+		t.Fatalf("error consuming token: %v", err)
+	}
+
+	if token.UserID != "user" || token.Email != "user@example.com" || !token.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected token: %+v", token)
+	}
+
+	_, err = adapter.ConsumeToken(ctx, "a")
+	if !errors.Is(err, verification.ErrTokenNotFound) {
+		t.Fatalf("expected token to be single use, got %v", err)
+	}
+}
+
+func TestSQLAdapterReplaceToken(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	err := adapter.ReplaceToken(ctx, newTestToken("a", "user", now), now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("error replacing token: %v", err)
+	}
+
+	err = adapter.ReplaceToken(ctx, newTestToken("b", "user", now.Add(30*time.Second)), now.Add(-30*time.Second))
+	if !errors.Is(err, verification.ErrResendThrottled) {
+		t.Fatalf("expected ErrResendThrottled, got %v", err)
+	}
+
+	err = adapter.ReplaceToken(ctx, newTestToken("c", "other", now.Add(30*time.Second)), now.Add(-30*time.Second))
+	if err != nil {
+		t.Fatalf("expected other users not to be throttled, got %v", err)
+	}
+
+	err = adapter.ReplaceToken(ctx, newTestToken("d", "user", now.Add(time.Minute)), now)
+	if err != nil {
+		t.Fatalf("error replacing token: %v", err)
+	}
+
+	for tokenHash, expected := range map[string]error{"a": verification.ErrTokenNotFound, "b": verification.ErrTokenNotFound, "c": nil, "d": nil} {
+		_, err = adapter.ConsumeToken(ctx, tokenHash)
+		if !errors.Is(err, expected) {
+			t.Errorf("expected %v consuming %q, got %v", expected, tokenHash, err)
+		}
+	}
+}
+
+func TestSQLAdapterReplaceTokenConcurrently(t *testing.T) {
+	ctx := context.Background()
+	adapter, _ := newTestAdapter(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	replaced := atomic.Int32{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := adapter.ReplaceToken(ctx, newTestToken(fmt.Sprint(i), "user", now), now.Add(-time.Minute))
+			if err == nil {
+				replaced.Add(1)
+			} else if !errors.Is(err, verification.ErrResendThrottled) {
+				t.Errorf("error replacing token: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if replaced.Load() != 1 {
+		t.Fatalf("expected 1 token to be inserted, got %d", replaced.Load())
+	}
+}
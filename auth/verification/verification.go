@@ -0,0 +1,181 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/generators"
+)
+
+var (
+	// ErrTokenNotFound is returned when a token does not exist or has already
+	// been used. Adapters should return this error when a token cannot be
+	// found.
+	ErrTokenNotFound = errors.New("verification token not found")
+	// ErrTokenExpired is returned when a token exists but has expired.
+	ErrTokenExpired = errors.New("verification token is expired")
+	// ErrResendThrottled is returned when a token is requested before
+	// ResendInterval has passed since the previous token was sent.
+	ErrResendThrottled = errors.New("verification token was sent too recently")
+)
+
+// VerificationToken is a token that proves the user owns an email address.
+// Only the hash of the token is stored.
+type VerificationToken struct {
+	TokenHash string
+	UserID    string
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Adapter is responsible for storing verification tokens in a datastore of
+// your choice.
+type Adapter interface {
+	// ReplaceToken deletes all of the user's tokens and inserts the token in
+	// their place. When the user has a token that was created after
+	// throttledAfter nothing is changed and ErrResendThrottled is returned. It
+	// must be atomic so that concurrent calls cannot both insert a token.
+	ReplaceToken(ctx context.Context, token VerificationToken, throttledAfter time.Time) error
+	// ConsumeToken deletes the token with the given hash and returns it. It
+	// must be atomic so that a token can only be consumed once, even by
+	// concurrent requests. It returns ErrTokenNotFound when the token does not
+	// exist.
+	ConsumeToken(ctx context.Context, tokenHash string) (VerificationToken, error)
+}
+
+// Service sends single use, expiring tokens to email addresses and verifies
+// them.
+type Service struct {
+	adapter        Adapter
+	generator      auth.Generator
+	hasher         auth.Hasher
+	mailer         Mailer
+	compose        Compose
+	ttl            time.Duration
+	resendInterval time.Duration
+	now            func() time.Time
+}
+
+// Compose returns the message that delivers the token to the email address.
+type Compose func(email string, token string) Message
+
+type NewOptions struct {
+	Adapter Adapter
+	Mailer  Mailer
+	// Generator generates the tokens. Defaults to 20 random bytes encoded as
+	// lowercase base32.
+	Generator auth.Generator
+	// Hasher hashes the tokens before they are stored. Defaults to SHA-256.
+	Hasher auth.Hasher
+	// Compose builds the message sent to the user. Usually it links to a page
+	// that calls VerifyToken. Defaults to a message containing just the token.
+	Compose Compose
+	// TTL is how long a token is valid for. Defaults to 24 hours.
+	TTL time.Duration
+	// ResendInterval is the minimum time between two tokens for the same
+	// user. Defaults to 1 minute.
+	ResendInterval time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// New returns a new instance of Service.
+func New(options NewOptions) *Service {
+	s := &Service{
+		adapter:        options.Adapter,
+		generator:      options.Generator,
+		hasher:         options.Hasher,
+		mailer:         options.Mailer,
+		compose:        options.Compose,
+		ttl:            options.TTL,
+		resendInterval: options.ResendInterval,
+		now:            options.Now,
+	}
+
+	if s.generator == nil {
+		s.generator = generators.NewBase32LowerGenerator(20)
+	}
+
+	if s.hasher == nil {
+		s.hasher = auth.NewSha256Hasher()
+	}
+
+	if s.compose == nil {
+		s.compose = defaultCompose
+	}
+
+	if s.ttl == 0 {
+		s.ttl = 24 * time.Hour
+	}
+
+	if s.resendInterval == 0 {
+		s.resendInterval = time.Minute
+	}
+
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	return s
+}
+
+// SendToken creates a token for the email address and mails it. Any tokens
+// the user was sent before are deleted so that only the newest one works. It
+// returns an error wrapping ErrResendThrottled when the previous token was
+// sent less than ResendInterval ago.
+func (s *Service) SendToken(ctx context.Context, userID string, email string) error {
+	now := s.now()
+
+	token, err := s.generator.Generate()
+	if err != nil {
+		return fmt.Errorf("error generating verification token: %w", err)
+	}
+
+	err = s.adapter.ReplaceToken(ctx, VerificationToken{
+		TokenHash: s.hasher.Hash(token),
+		UserID:    userID,
+		Email:     email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}, now.Add(-s.resendInterval))
+	if errors.Is(err, ErrResendThrottled) {
+		return fmt.Errorf("%w: tokens can be sent every %s", ErrResendThrottled, s.resendInterval)
+	} else if err != nil {
+		return fmt.Errorf("error replacing verification token: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, s.compose(email, token))
+	if err != nil {
+		return fmt.Errorf("error sending verification token: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyToken consumes the token and returns it. The returned token contains
+// the user and email address that were verified. A token can only be verified
+// once.
+func (s *Service) VerifyToken(ctx context.Context, token string) (VerificationToken, error) {
+	verificationToken, err := s.adapter.ConsumeToken(ctx, s.hasher.Hash(token))
+	if err != nil {
+		return VerificationToken{}, fmt.Errorf("error consuming verification token: %w", err)
+	}
+
+	if verificationToken.ExpiresAt.Before(s.now()) {
+		return VerificationToken{}, fmt.Errorf("%w: %s", ErrTokenExpired, verificationToken.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return verificationToken, nil
+}
+
+func defaultCompose(email string, token string) Message {
+	return Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Your verification code is %s", token),
+	}
+}
@@ -0,0 +1,172 @@
+package verification_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lukeshay/g/auth/verification"
+)
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func newTestService(t *testing.T) (*verification.Service, *verification.InMemoryMailer, *clock) {
+	t.Helper()
+
+	mailer := verification.NewInMemoryMailer()
+	clock := &clock{now: time.Unix(1700000000, 0)}
+
+	service := verification.New(verification.NewOptions{
+		Adapter: verification.NewInMemoryAdapter(),
+		Mailer:  mailer,
+		Compose: func(email string, token string) verification.Message {
+			return verification.Message{To: email, Body: token}
+		},
+		Now: clock.Now,
+	})
+
+	return service, mailer, clock
+}
+
+func TestVerifyToken(t *testing.T) {
+	ctx := context.Background()
+	service, mailer, _ := newTestService(t)
+
+	err := service.SendToken(ctx, "user", "user@example.com")
+	if err != nil {
+		t.Fatalf("error sending token: %v", err)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 || messages[0].To != "user@example.com" {
+		t.Fatalf("expected 1 message to user@example.com, got %+v", messages)
+	}
+
+	token, err := service.VerifyToken(ctx, messages[0].Body)
+	if err != nil {
+		t.Fatalf("error verifying token: %v", err)
+	}
+
+	if token.UserID != "user" || token.Email != "user@example.com" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+
+	_, err = service.VerifyToken(ctx, messages[0].Body)
+	if !errors.Is(err, verification.ErrTokenNotFound) {
+		t.Fatalf("expected token to be single use, got %v", err)
+	}
+
+	_, err = service.VerifyToken(ctx, "unknown")
+	if !errors.Is(err, verification.ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound, got %v", err)
+	}
+}
+
+func TestVerifyTokenExpired(t *testing.T) {
+	ctx := context.Background()
+	service, mailer, clock := newTestService(t)
+
+	err := service.SendToken(ctx, "user", "user@example.com")
+	if err != nil {
+		t.Fatalf("error sending token: %v", err)
+	}
+
+	clock.Add(24*time.Hour + time.Second)
+
+	_, err = service.VerifyToken(ctx, mailer.Messages()[0].Body)
+	if !errors.Is(err, verification.ErrTokenExpired) {
+		t.Fatalf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestSendTokenThrottle(t *testing.T) {
+	ctx := context.Background()
+	service, mailer, clock := newTestService(t)
+
+	err := service.SendToken(ctx, "user", "user@example.com")
+	if err != nil {
+		t.Fatalf("error sending token: %v", err)
+	}
+
+	clock.Add(30 * time.Second)
+
+	err = service.SendToken(ctx, "user", "user@example.com")
+	if !errors.Is(err, verification.ErrResendThrottled) {
+		t.Fatalf("expected ErrResendThrottled, got %v", err)
+	}
+
+	err = service.SendToken(ctx, "other", "other@example.com")
+	if err != nil {
+		t.Fatalf("expected other users not to be throttled, got %v", err)
+	}
+
+	clock.Add(30 * time.Second)
+
+	err = service.SendToken(ctx, "user", "user@example.com")
+	if err != nil {
+		t.Fatalf("error sending token: %v", err)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+
+	_, err = service.VerifyToken(ctx, messages[0].Body)
+	if !errors.Is(err, verification.ErrTokenNotFound) {
+		t.Fatalf("expected the previous token to be replaced, got %v", err)
+	}
+
+	_, err = service.VerifyToken(ctx, messages[2].Body)
+	if err != nil {
+		t.Fatalf("error verifying token: %v", err)
+	}
+}
+
+func TestSendTokenConcurrently(t *testing.T) {
+	ctx := context.Background()
+	service, mailer, _ := newTestService(t)
+	throttled := atomic.Int32{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := service.SendToken(ctx, "user", "user@example.com")
+			if errors.Is(err, verification.ErrResendThrottled) {
+				throttled.Add(1)
+			} else if err != nil {
+				t.Errorf("error sending token: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(mailer.Messages()) != 1 || throttled.Load() != 9 {
+		t.Fatalf("expected 1 message and 9 throttled requests, got %d and %d", len(mailer.Messages()), throttled.Load())
+	}
+}
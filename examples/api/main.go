@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"github.com/uptrace/bun/extra/bundebug"
 
 	"github.com/lukeshay/g/auth"
+	"github.com/lukeshay/g/auth/adapters/sqladapter"
 	"github.com/lukeshay/g/auth/encrypters"
 	"github.com/lukeshay/g/auth/generators"
 	"github.com/lukeshay/g/auth/netauth"
@@ -28,7 +30,15 @@ func main() {
 	}
 
 	db := bun.NewDB(sqldb, sqlitedialect.New())
-	encrypter, err := encrypters.NewAesEncrypter("thisissupersecret")
+	key, err := encrypters.KeyFromSecret([]byte("thisissupersecret"), "session cookie")
+	if err != nil {
+		panic(err)
+	}
+
+	encrypter, err := encrypters.NewAesGcmEncrypter(encrypters.NewAesGcmEncrypterOptions{
+		Key:          key,
+		LegacySecret: "thisissupersecret",
+	})
 	if err != nil {
 		panic(err)
 	}
@@ -38,17 +48,15 @@ func main() {
 		bundebug.FromEnv("BUNDEBUG"),
 	))
 
-	err = db.ResetModel(context.Background(), (*Session)(nil))
+	err = sqladapter.Migrate(context.Background(), db)
 	if err != nil {
 		panic(err)
 	}
 
 	authManager := netauth.New(netauth.NewOptions{
-		Adapter: &Adapter{
-			db: db,
-		},
+		Adapter:   sqladapter.New[sqladapter.Session](sqladapter.NewOptions{DB: db}),
 		Encrypter: encrypter,
-		Generator: generators.NewBase32Generator(15),
+		Generator: generators.NewBase32LowerGenerator(15),
 		Validate: func(ctx context.Context, r *http.Request, s auth.Session) (context.Context, error) {
 			return ctx, nil
 		},
@@ -67,7 +75,7 @@ func main() {
 			return
 		}
 
-		_, session, err := authManager.CreateNewSession(r.Context(), w, &Session{
+		_, session, err := authManager.CreateNewSession(r.Context(), w, &sqladapter.Session{
 			UserID:       string(body),
 			ExpiresAt:    time.Now().Add(time.Hour),
 			RefreshUntil: time.Now().Add(time.Hour * 24),
@@ -94,7 +102,7 @@ func main() {
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, session, err := authManager.GetSessionAndRefresh(r.Context(), w, r, time.Now().Add(time.Hour))
 		if err != nil {
-			w.Write([]byte(fmt.Sprintf("Error getting session: %s", err.Error())))
+			writeSessionError(w, err)
 			return
 		}
 
@@ -111,9 +119,66 @@ func main() {
 		w.Write([]byte("Session invalidated"))
 	})
 
+	http.HandleFunc("/sessions", func(w http.ResponseWriter, r *http.Request) {
+		ctx, session, err := authManager.GetSession(r.Context(), r)
+		if err != nil {
+			writeSessionError(w, err)
+			return
+		}
+
+		sessions, err := authManager.Service().ListUserSessions(ctx, session.GetUserID())
+		if err != nil {
+			w.Write([]byte(fmt.Sprintf("Error listing sessions: %s", err.Error())))
+			return
+		}
+
+		currentSessionID := authManager.Service().HashSessionID(session.GetSessionID())
+		res := []map[string]any{}
+		for _, s := range sessions {
+			res = append(res, map[string]any{
+				"current":   s.GetSessionID() == currentSessionID,
+				"expiresAt": s.GetExpiresAt().Format(time.RFC3339),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	})
+
+	http.HandleFunc("/signout/others", func(w http.ResponseWriter, r *http.Request) {
+		ctx, session, err := authManager.GetSession(r.Context(), r)
+		if err != nil {
+			writeSessionError(w, err)
+			return
+		}
+
+		err = authManager.Service().DeleteOtherSessions(ctx, session.GetUserID(), session.GetSessionID())
+		if err != nil {
+			w.Write([]byte(fmt.Sprintf("Error invalidating sessions: %s", err.Error())))
+			return
+		}
+
+		w.Write([]byte("Other sessions invalidated"))
+	})
+
 	fmt.Println("Listening on :8080")
 	err = http.ListenAndServe(":8080", nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting server: %s", err.Error())
 	}
 }
+
+// writeSessionError responds with 401 when the request does not have a valid
+// session, with 403 when the session has not verified enough factors, and
+// with 500 when the session could not be retrieved.
+func writeSessionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, auth.ErrInsufficientAuthenticationLevel) {
+		w.WriteHeader(http.StatusForbidden)
+	} else if errors.Is(err, auth.ErrSessionNotFound) || errors.Is(err, auth.ErrSessionExpired) || errors.Is(err, auth.ErrInvalidCookie) {
+		w.WriteHeader(http.StatusUnauthorized)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	w.Write([]byte(fmt.Sprintf("Error getting session: %s", err.Error())))
+}
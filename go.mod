@@ -11,6 +11,7 @@ require (
 	github.com/uptrace/bun/driver/sqliteshim v1.2.3
 	github.com/uptrace/bun/extra/bundebug v1.2.3
 	github.com/valyala/fasthttp v1.56.0
+	golang.org/x/crypto v0.28.0
 	gopkg.in/DataDog/dd-trace-go.v1 v1.69.0
 )
 
@@ -60,7 +61,6 @@ require (
 	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
 	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	go.uber.org/atomic v1.11.0 // indirect
-	golang.org/x/crypto v0.28.0 // indirect
 	golang.org/x/exp v0.0.0-20241009180824-f66d83c29e7c // indirect
 	golang.org/x/mod v0.21.0 // indirect
 	golang.org/x/net v0.30.0 // indirect